@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"sea/infra"
+	"sea/zlog"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Rebuilder recomputes derived data, such as graph edges, from scratch
+type Rebuilder interface {
+	Rebuild(ctx context.Context) (int, error)
+}
+
+// AdminHandler serves maintenance endpoints behind a bearer token. Without
+// a token the endpoints are not registered at all.
+type AdminHandler struct {
+	token string
+	jobs  map[string]Rebuilder
+	// start runs a job in the background; infra.Go outside tests
+	start func(fn func(ctx context.Context)) error
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func NewAdminHandler(token string) *AdminHandler {
+	return &AdminHandler{
+		token:   token,
+		jobs:    make(map[string]Rebuilder),
+		start:   infra.Go,
+		running: make(map[string]bool),
+	}
+}
+
+// AddJob makes job available as POST /v1/admin/rebuild/<name>
+func (h *AdminHandler) AddJob(name string, job Rebuilder) *AdminHandler {
+	h.jobs[name] = job
+	return h
+}
+
+func (h *AdminHandler) Register(r gin.IRouter) {
+	if h.token == "" {
+		return
+	}
+	admin := r.Group("/v1/admin", h.authorize)
+	admin.POST("/rebuild/:job", h.Rebuild)
+}
+
+func (h *AdminHandler) authorize(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+// Rebuild starts a full rebuild in the background and returns 202 at once.
+// A job that is already running is not started twice.
+func (h *AdminHandler) Rebuild(c *gin.Context) {
+	name := c.Param("job")
+	job, ok := h.jobs[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown job " + name})
+		return
+	}
+
+	h.mu.Lock()
+	if h.running[name] {
+		h.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "job " + name + " is already running"})
+		return
+	}
+	h.running[name] = true
+	h.mu.Unlock()
+
+	err := h.start(func(ctx context.Context) {
+		defer h.finish(name)
+		n, err := job.Rebuild(ctx)
+		if err != nil {
+			zlog.L().Error("rebuild failed", zap.String("job", name), zap.Int("edges", n), zap.Error(err))
+			return
+		}
+		zlog.L().Info("rebuild done", zap.String("job", name), zap.Int("edges", n))
+	})
+	if err != nil {
+		h.finish(name)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"job": name, "status": "started"})
+}
+
+func (h *AdminHandler) finish(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, name)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRebuilder 记录调用次数
+type stubRebuilder struct {
+	calls int
+}
+
+func (s *stubRebuilder) Rebuild(ctx context.Context) (int, error) {
+	s.calls++
+	return 3, nil
+}
+
+func newAdminRouter(h *AdminHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h.Register(r)
+	return r
+}
+
+func doPost(r http.Handler, path, token string) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+// 带正确令牌时在后台启动重建
+func TestAdminRebuild(t *testing.T) {
+	job := &stubRebuilder{}
+	h := NewAdminHandler("secret").AddJob("similarity", job)
+	h.start = func(fn func(ctx context.Context)) error {
+		fn(context.Background())
+		return nil
+	}
+	r := newAdminRouter(h)
+
+	assert.Equal(t, http.StatusUnauthorized, doPost(r, "/v1/admin/rebuild/similarity", ""))
+	assert.Equal(t, http.StatusUnauthorized, doPost(r, "/v1/admin/rebuild/similarity", "wrong"))
+	assert.Equal(t, 0, job.calls)
+
+	assert.Equal(t, http.StatusAccepted, doPost(r, "/v1/admin/rebuild/similarity", "secret"))
+	assert.Equal(t, 1, job.calls)
+	// 上一次跑完之后可以再次触发
+	assert.Equal(t, http.StatusAccepted, doPost(r, "/v1/admin/rebuild/similarity", "secret"))
+	assert.Equal(t, 2, job.calls)
+
+	assert.Equal(t, http.StatusNotFound, doPost(r, "/v1/admin/rebuild/unknown", "secret"))
+}
+
+// 同一个任务还在跑时不重复启动
+func TestAdminRebuildAlreadyRunning(t *testing.T) {
+	job := &stubRebuilder{}
+	h := NewAdminHandler("secret").AddJob("similarity", job)
+	var pending func(ctx context.Context)
+	h.start = func(fn func(ctx context.Context)) error {
+		pending = fn
+		return nil
+	}
+	r := newAdminRouter(h)
+
+	assert.Equal(t, http.StatusAccepted, doPost(r, "/v1/admin/rebuild/similarity", "secret"))
+	assert.Equal(t, http.StatusConflict, doPost(r, "/v1/admin/rebuild/similarity", "secret"))
+	pending(context.Background())
+	assert.Equal(t, 1, job.calls)
+	assert.Equal(t, http.StatusAccepted, doPost(r, "/v1/admin/rebuild/similarity", "secret"))
+}
+
+// 没配置令牌时不开放管理接口
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	r := newAdminRouter(NewAdminHandler("").AddJob("similarity", &stubRebuilder{}))
+	assert.Equal(t, http.StatusNotFound, doPost(r, "/v1/admin/rebuild/similarity", ""))
+}
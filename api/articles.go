@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sea/ingest"
+	"sea/zlog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type articleRequest struct {
+	ArticleID string     `json:"article_id" binding:"required"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body" binding:"required"`
+	Tags      []string   `json:"tags"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// ArticleHandler serves the ingest endpoint
+type ArticleHandler struct {
+	ingest *ingest.Service
+}
+
+func NewArticleHandler(svc *ingest.Service) *ArticleHandler {
+	return &ArticleHandler{ingest: svc}
+}
+
+func (h *ArticleHandler) Register(r gin.IRouter) {
+	r.POST("/v1/articles", h.Create)
+}
+
+// Create ingests an article. Posting the same article_id again replaces
+// its chunks, so clients can retry safely.
+func (h *ArticleHandler) Create(c *gin.Context) {
+	var req articleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	article := ingest.Article{
+		ArticleID: req.ArticleID,
+		Title:     req.Title,
+		Body:      req.Body,
+		Tags:      req.Tags,
+	}
+	if req.CreatedAt != nil {
+		article.CreatedAt = *req.CreatedAt
+	}
+
+	res, err := h.ingest.Ingest(c.Request.Context(), article)
+	if err != nil {
+		if errors.Is(err, ingest.ErrInvalidArticle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		zlog.L().Error("article ingest failed", zap.String("article_id", req.ArticleID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+
+	defaultProbeTimeout = 3 * time.Second
+)
+
+// Probe reports whether one dependency is usable; nil means ready
+type Probe func(ctx context.Context) error
+
+// CheckResult is the status of one dependency in the /readyz body
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the /readyz body
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+	// Stats holds in-process counters such as cache hit rates; they never
+	// affect Status
+	Stats map[string]any `json:"stats,omitempty"`
+}
+
+// Stats returns a JSON friendly snapshot, or nil when there is nothing to
+// report
+type Stats func() any
+
+// Health serves /healthz and /readyz. Liveness never touches dependencies so
+// a slow database does not get the pod restarted; readiness runs every probe
+// concurrently under a shared timeout.
+type Health struct {
+	timeout time.Duration
+	names   []string
+	probes  map[string]Probe
+	stats   map[string]Stats
+}
+
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	return &Health{timeout: timeout, probes: make(map[string]Probe), stats: make(map[string]Stats)}
+}
+
+// Add registers a readiness probe, replacing any probe with the same name
+func (h *Health) Add(name string, probe Probe) {
+	if _, ok := h.probes[name]; !ok {
+		h.names = append(h.names, name)
+		sort.Strings(h.names)
+	}
+	h.probes[name] = probe
+}
+
+// AddStats reports stats under name in the /readyz body
+func (h *Health) AddStats(name string, stats Stats) {
+	h.stats[name] = stats
+}
+
+func (h *Health) Register(r gin.IRouter) {
+	r.GET("/healthz", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+func (h *Health) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+func (h *Health) Ready(c *gin.Context) {
+	report := h.Check(c.Request.Context())
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// Check runs all probes and aggregates their results
+func (h *Health) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(h.names))
+	var wg sync.WaitGroup
+	for i, name := range h.names {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			start := time.Now()
+			err := probe(ctx)
+			res := CheckResult{Status: StatusOK, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				res.Status = StatusUnavailable
+				res.Error = err.Error()
+			}
+			results[i] = res
+		}(i, h.probes[name])
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(h.names))}
+	for i, name := range h.names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	for name, stats := range h.stats {
+		if v := stats(); v != nil {
+			if report.Stats == nil {
+				report.Stats = make(map[string]any, len(h.stats))
+			}
+			report.Stats[name] = v
+		}
+	}
+	return report
+}
+
+// CachedProbe reuses the last success of probe for ttl. It keeps expensive
+// probes such as a real embedding call from running on every kubelet poll.
+// Failures are not cached, so the next poll retries and a transient error
+// does not keep the pod unready for a whole ttl.
+func CachedProbe(probe Probe, ttl time.Duration) Probe {
+	var (
+		mu sync.Mutex
+		ok time.Time
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ok.IsZero() && time.Since(ok) < ttl {
+			return nil
+		}
+		if err := probe(ctx); err != nil {
+			return err
+		}
+		ok = time.Now()
+		return nil
+	}
+}
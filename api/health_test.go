@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(h *Health) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h.Register(r)
+	return r
+}
+
+func doGet(t *testing.T, r http.Handler, path string) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+// 所有依赖正常时返回 200
+func TestReadyAllOK(t *testing.T) {
+	h := NewHealth(time.Second)
+	h.Add("milvus", func(ctx context.Context) error { return nil })
+	h.Add("neo4j", func(ctx context.Context) error { return nil })
+
+	code, report := doGet(t, newTestRouter(h), "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, StatusOK, report.Checks["neo4j"].Status)
+}
+
+// 任一依赖失败返回 503，并带上各自的状态
+func TestReadyOneDown(t *testing.T) {
+	h := NewHealth(time.Second)
+	h.Add("milvus", func(ctx context.Context) error { return nil })
+	h.Add("neo4j", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	code, report := doGet(t, newTestRouter(h), "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["milvus"].Status)
+	assert.Equal(t, StatusUnavailable, report.Checks["neo4j"].Status)
+	assert.Equal(t, "connection refused", report.Checks["neo4j"].Error)
+}
+
+// 统计信息随 /readyz 返回，不影响状态
+func TestReadyStats(t *testing.T) {
+	h := NewHealth(time.Second)
+	h.Add("milvus", func(ctx context.Context) error { return nil })
+	h.AddStats("embedding_cache", func() any { return map[string]int{"hits": 3, "misses": 1} })
+	h.AddStats("disabled", func() any { return nil })
+
+	code, report := doGet(t, newTestRouter(h), "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{
+		"embedding_cache": map[string]any{"hits": float64(3), "misses": float64(1)},
+	}, report.Stats)
+}
+
+// 卡住的探针受总超时限制
+func TestReadyTimeout(t *testing.T) {
+	h := NewHealth(50 * time.Millisecond)
+	h.Add("kafka", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := doGet(t, newTestRouter(h), "/readyz")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, report.Checks["kafka"].Error, "deadline")
+}
+
+// 存活检查不调用任何探针
+func TestLiveIgnoresProbes(t *testing.T) {
+	h := NewHealth(time.Second)
+	var calls int32
+	h.Add("milvus", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("down")
+	})
+
+	code, report := doGet(t, newTestRouter(h), "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Zero(t, atomic.LoadInt32(&calls))
+}
+
+// 缓存探针在 TTL 内复用上次成功，失败不缓存
+func TestCachedProbe(t *testing.T) {
+	var calls int32
+	fail := true
+	probe := CachedProbe(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		if fail {
+			return errors.New("embedding failed")
+		}
+		return nil
+	}, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, probe(context.Background()), "embedding failed")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	fail = false
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, probe(context.Background()))
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sea/config"
+	"sea/embedding/chunker"
+	"sea/embedding/keyword"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/embedding/similarity"
+	"sea/infra"
+	"sea/ingest"
+	"sea/recall"
+	"sea/zlog"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// embeddingProbeText is embedded by the readiness probe, bypassing the
+// embedding cache so the provider is really reached
+const embeddingProbeText = "readiness probe"
+
+// NewRouter builds the HTTP handler with all routes of the service
+func NewRouter(cfg config.Config) *gin.Engine {
+	router := gin.Default()
+	router.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "pong",
+		})
+	})
+
+	NewReadiness(cfg.Server.Readiness).Register(router)
+
+	graphRepo := graph.NewRepository(infra.Neo4j(), cfg.Neo4j.Database)
+	searcher := recall.NewVectorSearch(infra.Milvus())
+	metric := schema.MetricType(cfg.Milvus.Index)
+	keywordEdges := keyword.NewEdgeJobFromConfig(cfg.Keyword, graphRepo)
+	similarEdges := similarity.NewJob(similarity.NewMilvusVectors(infra.Milvus()), searcher, graphRepo, similarity.Options{
+		TopK:     cfg.Similarity.TopK,
+		MinScore: cfg.Similarity.MinScore,
+		Metric:   metric,
+	})
+	ingestSvc := ingest.NewService(
+		service.Default(),
+		ingest.NewMilvusStore(infra.Milvus()),
+		graphRepo,
+		chunker.OptionsFromConfig(cfg.Chunking),
+		cfg.Embedding.BatchConcurrency,
+	).
+		WithAnnotator(keyword.NewExtractorFromConfig(cfg.Keyword).Annotate).
+		OnStored(func(_ context.Context, res ingest.Result) {
+			runInBackground("keyword edges", res.ArticleID, func(ctx context.Context) (int, error) {
+				return keywordEdges.BuildFor(ctx, res.ChildChunkIDs)
+			})
+			runInBackground("similarity edges", res.ArticleID, func(ctx context.Context) (int, error) {
+				return similarEdges.BuildFor(ctx, res.ChildChunkIDs)
+			})
+		})
+	NewArticleHandler(ingestSvc).Register(router)
+	NewAdminHandler(cfg.Server.AdminToken).
+		AddJob("keyword", keywordEdges).
+		AddJob("similarity", similarEdges).
+		Register(router)
+	NewSearchHandler(recall.NewEngine(
+		service.Default(),
+		searcher,
+		graphRepo,
+		metric,
+		cfg.Recall,
+	)).Register(router)
+	return router
+}
+
+// NewReadiness wires the probes of the external dependencies
+func NewReadiness(cfg config.ReadinessConfig) *Health {
+	h := NewHealth(cfg.Timeout)
+	h.Add("milvus", infra.CheckMilvus)
+	h.Add("neo4j", infra.CheckNeo4j)
+	h.Add("kafka", infra.CheckKafka)
+	h.AddStats("embedding_cache", func() any {
+		if s, ok := service.DefaultCacheStats(); ok {
+			return s
+		}
+		return nil
+	})
+	if cfg.EmbeddingProbe {
+		h.Add("embedding", CachedProbe(func(ctx context.Context) error {
+			_, err := service.Uncached().EmbedText(ctx, embeddingProbeText)
+			return err
+		}, cfg.EmbeddingProbeTTL))
+	}
+	return h
+}
+
+// runInBackground runs a post-ingest job as a tracked worker so shutdown
+// waits for it; failures are logged since the article itself is stored
+func runInBackground(job, articleID string, fn func(ctx context.Context) (int, error)) {
+	err := infra.Go(func(ctx context.Context) {
+		n, err := fn(ctx)
+		if err != nil {
+			zlog.L().Error("post-ingest job failed",
+				zap.String("job", job), zap.String("article_id", articleID), zap.Error(err))
+			return
+		}
+		zlog.L().Info("post-ingest job done",
+			zap.String("job", job), zap.String("article_id", articleID), zap.Int("edges", n))
+	})
+	if err != nil {
+		zlog.L().Warn("post-ingest job skipped",
+			zap.String("job", job), zap.String("article_id", articleID), zap.Error(err))
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sea/recall"
+	"sea/zlog"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type searchRequest struct {
+	Query         string `json:"query" binding:"required"`
+	Mode          string `json:"mode"`
+	TopK          int    `json:"top_k"`
+	CandidateTopK int    `json:"candidate_top_k"`
+	// Filter is structured on purpose; raw milvus expressions are not accepted
+	Filter *recall.Filter `json:"filter"`
+	// Fusion applies to mode "hybrid"
+	Fusion *recall.Fusion `json:"fusion"`
+	// Expand adds graph neighbors of the hits with their provenance
+	Expand *recall.ExpandOptions `json:"expand"`
+}
+
+// SearchHandler serves the recall search endpoint
+type SearchHandler struct {
+	engine *recall.Engine
+}
+
+func NewSearchHandler(engine *recall.Engine) *SearchHandler {
+	return &SearchHandler{engine: engine}
+}
+
+func (h *SearchHandler) Register(r gin.IRouter) {
+	r.POST("/v1/search", h.Search)
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	var req searchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q := recall.Query{
+		Text:          req.Query,
+		Mode:          req.Mode,
+		TopK:          req.TopK,
+		CandidateTopK: req.CandidateTopK,
+		Filter:        req.Filter,
+		Fusion:        req.Fusion,
+		Expand:        req.Expand,
+	}
+
+	res, err := h.engine.Search(c.Request.Context(), q)
+	if err != nil {
+		if errors.Is(err, recall.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		zlog.L().Error("search failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
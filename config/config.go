@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"sea/zlog"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -11,10 +13,39 @@ import (
 var Cfg Config
 
 type Config struct {
+	Server ServerConfig `mapstructure:"server" yaml:"server"`
 	Milvus MilvusConfig `mapstructure:"milvus" yaml:"milvus"`
 	Ali    AliConfig    `mapstructure:"ali" yaml:"ali"`
 	Kafka  KafkaConfig  `mapstructure:"Kafka" yaml:"Kafka"` // note: key is "Kafka" in your YAML
 	Neo4j  Neo4jConfig  `mapstructure:"neo4j" yaml:"neo4j"`
+
+	Embedding  EmbeddingConfig  `mapstructure:"embedding" yaml:"embedding"`
+	Redis      RedisConfig      `mapstructure:"redis" yaml:"redis"`
+	Chunking   ChunkingConfig   `mapstructure:"chunking" yaml:"chunking"`
+	Recall     RecallConfig     `mapstructure:"recall" yaml:"recall"`
+	Keyword    KeywordConfig    `mapstructure:"keyword" yaml:"keyword"`
+	Similarity SimilarityConfig `mapstructure:"similarity" yaml:"similarity"`
+}
+
+type ServerConfig struct {
+	Address           string        `mapstructure:"address" yaml:"address"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" yaml:"idle_timeout"`
+	// ShutdownTimeout bounds the drain of in-flight requests and workers
+	ShutdownTimeout time.Duration   `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout"`
+	Readiness       ReadinessConfig `mapstructure:"readiness" yaml:"readiness"`
+	// AdminToken guards the /v1/admin endpoints; empty disables them
+	AdminToken string `mapstructure:"admin_token" yaml:"admin_token"`
+}
+
+type ReadinessConfig struct {
+	// Timeout bounds one /readyz request across all probes
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// EmbeddingProbe embeds a fixed text; a success is reused for EmbeddingProbeTTL
+	EmbeddingProbe    bool          `mapstructure:"embedding_probe" yaml:"embedding_probe"`
+	EmbeddingProbeTTL time.Duration `mapstructure:"embedding_probe_ttl" yaml:"embedding_probe_ttl"`
 }
 
 type MilvusConfig struct {
@@ -22,6 +53,33 @@ type MilvusConfig struct {
 	Username string `mapstructure:"username" yaml:"username"`
 	Password string `mapstructure:"password" yaml:"password"`
 	DBName   string `mapstructure:"dbname" yaml:"dbname"`
+	// DimensionProbe embeds a probe text at startup to verify the model output length
+	DimensionProbe bool              `mapstructure:"dimension_probe" yaml:"dimension_probe"`
+	Index          MilvusIndexConfig `mapstructure:"index" yaml:"index"`
+	// The client multiplexes one gRPC connection, so there is no pool to size;
+	// these bound connecting and retries on server side rate limiting
+	ConnectTimeout      time.Duration `mapstructure:"connect_timeout" yaml:"connect_timeout"`
+	RateLimitRetries    int           `mapstructure:"rate_limit_retries" yaml:"rate_limit_retries"`
+	RateLimitMaxBackoff time.Duration `mapstructure:"rate_limit_max_backoff" yaml:"rate_limit_max_backoff"`
+}
+
+// MilvusIndexConfig configures the vector indexes of the recall collections.
+// Metric is COSINE, IP or L2 and applies to both.
+type MilvusIndexConfig struct {
+	Metric    string            `mapstructure:"metric" yaml:"metric"`
+	Candidate VectorIndexConfig `mapstructure:"candidate" yaml:"candidate"`
+	Precise   VectorIndexConfig `mapstructure:"precise" yaml:"precise"`
+	// TextAnalyzer tokenizes chunk text for BM25: standard, chinese or english
+	TextAnalyzer string `mapstructure:"text_analyzer" yaml:"text_analyzer"`
+}
+
+// VectorIndexConfig selects an index type and its build parameters.
+// M and EfConstruction apply to HNSW, NList to the IVF family.
+type VectorIndexConfig struct {
+	Type           string `mapstructure:"type" yaml:"type"`
+	M              int    `mapstructure:"m" yaml:"m"`
+	EfConstruction int    `mapstructure:"ef_construction" yaml:"ef_construction"`
+	NList          int    `mapstructure:"nlist" yaml:"nlist"`
 }
 
 type AliConfig struct {
@@ -31,16 +89,155 @@ type AliConfig struct {
 	TextModel         string `mapstructure:"text_model" yaml:"text_model"`
 	MultimodalModel   string `mapstructure:"multimodal_model" yaml:"multimodal_model"`
 	Dimensions        int    `mapstructure:"dimensions" yaml:"dimensions"`
+	// Timeout bounds every embedding call, e.g. "30s"; 0 means no limit
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// BatchSize caps texts per request; text-embedding-v4 accepts 10
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size"`
+	// Retry applies to the multimodal endpoint
+	Retry RetryConfig `mapstructure:"retry" yaml:"retry"`
+	// Image limits local images sent inline as base64 data URIs
+	Image ImageConfig `mapstructure:"image" yaml:"image"`
+}
+
+// ImageConfig limits inline images. MaxBytes applies to the encoded image;
+// images whose longer side exceeds MaxDimension are downscaled first, and
+// JPEG, PNG and GIF images still over MaxBytes are shrunk until they fit.
+type ImageConfig struct {
+	MaxBytes     int `mapstructure:"max_bytes" yaml:"max_bytes"`
+	MaxDimension int `mapstructure:"max_dimension" yaml:"max_dimension"`
+}
+
+// RetryConfig controls retries of transient failures (429, 5xx, network).
+// MaxAttempts counts the first try; 0 or 1 disables retrying.
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts" yaml:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff"`
+}
+
+// EmbeddingConfig selects the embedding backend.
+// Provider is one of dashscope (uses the ali section), openai or fake.
+type EmbeddingConfig struct {
+	Provider         string          `mapstructure:"provider" yaml:"provider"`
+	BatchConcurrency int             `mapstructure:"batch_concurrency" yaml:"batch_concurrency"` // batch requests in flight
+	OpenAI           OpenAIConfig    `mapstructure:"openai" yaml:"openai"`
+	Fake             FakeEmbedConfig `mapstructure:"fake" yaml:"fake"`
+	Cache            CacheConfig     `mapstructure:"cache" yaml:"cache"`
+	// RateLimits is keyed by model name, e.g. text-embedding-v4
+	RateLimits map[string]RateLimitConfig `mapstructure:"rate_limits" yaml:"rate_limits"`
+}
+
+// RateLimitConfig is the client side budget for one model.
+// Zero values disable the corresponding limit. FailFast returns an error
+// instead of waiting when the budget is exhausted.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"rps" yaml:"rps"`
+	TokensPerMinute   int     `mapstructure:"tpm" yaml:"tpm"`
+	FailFast          bool    `mapstructure:"fail_fast" yaml:"fail_fast"`
+}
+
+// CacheConfig controls the embedding cache.
+// Backend is memory (per process LRU) or redis (shared, uses the redis section).
+type CacheConfig struct {
+	Enabled   bool          `mapstructure:"enabled" yaml:"enabled"`
+	Backend   string        `mapstructure:"backend" yaml:"backend"`
+	Capacity  int           `mapstructure:"capacity" yaml:"capacity"` // memory backend only
+	TTL       time.Duration `mapstructure:"ttl" yaml:"ttl"`
+	KeyPrefix string        `mapstructure:"key_prefix" yaml:"key_prefix"` // redis backend only
+}
+
+// OpenAIConfig configures any OpenAI-compatible embeddings endpoint
+type OpenAIConfig struct {
+	APIKey     string        `mapstructure:"apikey" yaml:"apikey"`
+	BaseURL    string        `mapstructure:"baseurl" yaml:"baseurl"`
+	Model      string        `mapstructure:"model" yaml:"model"`
+	Dimensions int           `mapstructure:"dimensions" yaml:"dimensions"`
+	Timeout    time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	BatchSize  int           `mapstructure:"batch_size" yaml:"batch_size"`
+}
+
+// FakeEmbedConfig configures the deterministic offline embedder
+type FakeEmbedConfig struct {
+	Dimensions int `mapstructure:"dimensions" yaml:"dimensions"`
 }
 
 type KafkaConfig struct {
 	Address string `mapstructure:"address" yaml:"address"`
 }
 
+// ChunkingConfig sizes parent and child chunks. Unit is "char" (runes) or
+// "token" (CJK characters plus words, an estimate of model tokens).
+type ChunkingConfig struct {
+	Unit          string `mapstructure:"unit" yaml:"unit"`
+	ParentSize    int    `mapstructure:"parent_size" yaml:"parent_size"`
+	ParentOverlap int    `mapstructure:"parent_overlap" yaml:"parent_overlap"`
+	ChildSize     int    `mapstructure:"child_size" yaml:"child_size"`
+	ChildOverlap  int    `mapstructure:"child_overlap" yaml:"child_overlap"`
+}
+
+// RecallConfig holds the search defaults; requests may override the top-k
+// values up to the Max caps
+type RecallConfig struct {
+	TopK             int `mapstructure:"top_k" yaml:"top_k"`
+	CandidateTopK    int `mapstructure:"candidate_top_k" yaml:"candidate_top_k"`
+	MaxTopK          int `mapstructure:"max_top_k" yaml:"max_top_k"`
+	MaxCandidateTopK int `mapstructure:"max_candidate_top_k" yaml:"max_candidate_top_k"`
+}
+
+// KeywordConfig controls keyword extraction and SHARES_KEYWORD edges.
+// CorpusPath persists document frequencies across restarts when set; the
+// corpus is saved every FlushInterval when it changed and on shutdown. The
+// corpus is not shared between instances, so only one instance may ingest.
+// DictionaryPath is a word list (jieba dict.txt works) for segmenting
+// Chinese; without it Chinese is split into bigrams.
+type KeywordConfig struct {
+	TopN            int           `mapstructure:"top_n" yaml:"top_n"`
+	MinWeight       float64       `mapstructure:"min_weight" yaml:"min_weight"`
+	MaxEdgesPerNode int           `mapstructure:"max_edges_per_node" yaml:"max_edges_per_node"`
+	MaxDocFreqRatio float64       `mapstructure:"max_doc_freq_ratio" yaml:"max_doc_freq_ratio"`
+	CorpusPath      string        `mapstructure:"corpus_path" yaml:"corpus_path"`
+	DictionaryPath  string        `mapstructure:"dictionary_path" yaml:"dictionary_path"`
+	FlushInterval   time.Duration `mapstructure:"flush_interval" yaml:"flush_interval"`
+}
+
+// SimilarityConfig controls SIMILAR_TO edges between each chunk and its
+// TopK nearest chunks of other articles with cosine of at least MinScore
+type SimilarityConfig struct {
+	TopK     int     `mapstructure:"top_k" yaml:"top_k"`
+	MinScore float64 `mapstructure:"min_score" yaml:"min_score"`
+}
+
+type RedisConfig struct {
+	Address  string `mapstructure:"address" yaml:"address"`
+	Password string `mapstructure:"password" yaml:"password"`
+	DB       int    `mapstructure:"db" yaml:"db"`
+}
+
 type Neo4jConfig struct {
 	Address  string `mapstructure:"address" yaml:"address"`
 	Username string `mapstructure:"username" yaml:"username"`
 	Password string `mapstructure:"password" yaml:"password"`
+	// Database is empty for the server default
+	Database string `mapstructure:"database" yaml:"database"`
+	// Connection pool of the driver; zero values keep the driver defaults
+	MaxConnectionPoolSize        int           `mapstructure:"max_connection_pool_size" yaml:"max_connection_pool_size"`
+	ConnectionAcquisitionTimeout time.Duration `mapstructure:"connection_acquisition_timeout" yaml:"connection_acquisition_timeout"`
+	MaxConnectionLifetime        time.Duration `mapstructure:"max_connection_lifetime" yaml:"max_connection_lifetime"`
+}
+
+// EmbeddingDimensions returns the vector dimension of the selected embedding provider
+func (c Config) EmbeddingDimensions() int {
+	switch strings.ToLower(strings.TrimSpace(c.Embedding.Provider)) {
+	case "openai":
+		return c.Embedding.OpenAI.Dimensions
+	case "fake":
+		if c.Embedding.Fake.Dimensions > 0 {
+			return c.Embedding.Fake.Dimensions
+		}
+		return 2048
+	default:
+		return c.Ali.Dimensions
+	}
 }
 
 func Load(path string) error {
@@ -0,0 +1,276 @@
+// Package chunker splits articles into parent and child chunks for
+// small-to-big retrieval: children are embedded and searched, parents are
+// returned as context.
+package chunker
+
+import (
+	"errors"
+	"fmt"
+	"sea/config"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"strings"
+)
+
+const (
+	UnitChar  = "char"
+	UnitToken = "token"
+
+	// maxArticleIDLength is in bytes and leaves room for the longest chunk
+	// id suffix within the milvus primary key limit
+	maxArticleIDLength = schema.IDMaxLength - len("#p0000#c0000")
+
+	// MaxChunks caps the parents of an article and the children of a
+	// parent, so positions keep the four digits the id suffix reserves
+	MaxChunks = 9999
+)
+
+var (
+	ErrEmptyArticle  = errors.New("chunker: article has no content")
+	ErrInvalidID     = errors.New("chunker: invalid article id")
+	ErrTooManyChunks = fmt.Errorf("chunker: article splits into more than %d chunks per level", MaxChunks)
+)
+
+// Article is the input of the chunker
+type Article struct {
+	ArticleID string
+	Title     string
+	Body      string
+	Tags      []string
+}
+
+// Options sizes the chunks in Unit; overlaps are kept in whole sentences
+type Options struct {
+	Unit          string
+	ParentSize    int
+	ParentOverlap int
+	ChildSize     int
+	ChildOverlap  int
+}
+
+// DefaultOptions is used for every size left at zero
+var DefaultOptions = Options{
+	Unit:          UnitChar,
+	ParentSize:    1200,
+	ParentOverlap: 100,
+	ChildSize:     300,
+	ChildOverlap:  50,
+}
+
+// OptionsFromConfig fills unset values from DefaultOptions
+func OptionsFromConfig(cfg config.ChunkingConfig) Options {
+	return Options{
+		Unit:          cfg.Unit,
+		ParentSize:    cfg.ParentSize,
+		ParentOverlap: cfg.ParentOverlap,
+		ChildSize:     cfg.ChildSize,
+		ChildOverlap:  cfg.ChildOverlap,
+	}.withDefaults()
+}
+
+func (o Options) withDefaults() Options {
+	if o.Unit == "" {
+		o.Unit = DefaultOptions.Unit
+	}
+	if o.ParentSize <= 0 {
+		o.ParentSize = DefaultOptions.ParentSize
+	}
+	if o.ParentOverlap < 0 {
+		o.ParentOverlap = 0
+	}
+	if o.ChildSize <= 0 {
+		o.ChildSize = DefaultOptions.ChildSize
+	}
+	if o.ChildOverlap < 0 {
+		o.ChildOverlap = 0
+	}
+	return o
+}
+
+func (o Options) validate() error {
+	if o.Unit != UnitChar && o.Unit != UnitToken {
+		return fmt.Errorf("chunker: unknown unit %q", o.Unit)
+	}
+	if o.ChildSize > o.ParentSize {
+		return fmt.Errorf("chunker: child size %d exceeds parent size %d", o.ChildSize, o.ParentSize)
+	}
+	if o.ParentOverlap >= o.ParentSize || o.ChildOverlap >= o.ChildSize {
+		return errors.New("chunker: overlap must be smaller than chunk size")
+	}
+	return nil
+}
+
+// ParentChunk is a parent node with the text it covers
+type ParentChunk struct {
+	Node graph.ParentNode
+	// Heading is the markdown heading path, e.g. "Install > Docker"
+	Heading string
+	Text    string
+}
+
+// ChildChunk is a child node with the text to embed
+type ChildChunk struct {
+	Node    graph.ChildNode
+	Heading string
+	Text    string
+}
+
+// Result holds the chunks of one article in document order
+type Result struct {
+	Parents  []ParentChunk
+	Children []ChildChunk
+}
+
+func (r Result) ParentNodes() []graph.ParentNode {
+	out := make([]graph.ParentNode, len(r.Parents))
+	for i, p := range r.Parents {
+		out[i] = p.Node
+	}
+	return out
+}
+
+func (r Result) ChildNodes() []graph.ChildNode {
+	out := make([]graph.ChildNode, len(r.Children))
+	for i, c := range r.Children {
+		out[i] = c.Node
+	}
+	return out
+}
+
+// ParentChunkID and ChildChunkID derive ids from the position in the
+// article, so chunking the same article twice yields the same ids and a
+// re-ingest overwrites instead of duplicating
+func ParentChunkID(articleID string, parent int) string {
+	return fmt.Sprintf("%s#p%04d", articleID, parent)
+}
+
+func ChildChunkID(articleID string, parent, child int) string {
+	return fmt.Sprintf("%s#p%04d#c%04d", articleID, parent, child)
+}
+
+// Split chunks an article. Parents never cross a markdown heading and
+// children never cross their parent.
+func Split(article Article, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return Result{}, err
+	}
+	id := strings.TrimSpace(article.ArticleID)
+	if id == "" || len(id) > maxArticleIDLength || strings.Contains(id, "#") {
+		return Result{}, ErrInvalidID
+	}
+	if strings.TrimSpace(article.Body) == "" {
+		return Result{}, ErrEmptyArticle
+	}
+
+	measure := measureFunc(opts.Unit)
+	tag := primaryTag(article.Tags)
+	var res Result
+	for _, sec := range splitSections(article.Body) {
+		sentences := splitLong(splitSentences(sec.Text), opts.ChildSize, opts.Unit)
+		for _, group := range pack(sentences, opts.ParentSize, opts.ParentOverlap, measure) {
+			text := strings.TrimSpace(strings.Join(group, ""))
+			if text == "" {
+				continue
+			}
+			pi := len(res.Parents)
+			if pi >= MaxChunks {
+				return Result{}, ErrTooManyChunks
+			}
+			parentID := ParentChunkID(id, pi)
+			res.Parents = append(res.Parents, ParentChunk{
+				Node: graph.ParentNode{
+					NodeID:    parentID,
+					ArticleID: id,
+					ChunkID:   parentID,
+					Title:     article.Title,
+					Tag:       tag,
+				},
+				Heading: sec.Heading,
+				Text:    text,
+			})
+
+			ci := 0
+			for _, childGroup := range pack(group, opts.ChildSize, opts.ChildOverlap, measure) {
+				childText := strings.TrimSpace(strings.Join(childGroup, ""))
+				if childText == "" {
+					continue
+				}
+				if ci >= MaxChunks {
+					return Result{}, ErrTooManyChunks
+				}
+				childID := ChildChunkID(id, pi, ci)
+				ci++
+				res.Children = append(res.Children, ChildChunk{
+					Node: graph.ChildNode{
+						NodeID:       childID,
+						ArticleID:    id,
+						ParentNodeID: parentID,
+						ChunkID:      childID,
+						Title:        article.Title,
+						Tag:          tag,
+					},
+					Heading: sec.Heading,
+					Text:    childText,
+				})
+			}
+		}
+	}
+	if len(res.Parents) == 0 {
+		return Result{}, ErrEmptyArticle
+	}
+	return res, nil
+}
+
+// primaryTag is stored in the tag field; the first tag wins
+func primaryTag(tags []string) string {
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// pack groups consecutive sentences into chunks of at most size units.
+// Each chunk starts with the trailing sentences of the previous one that
+// fit in overlap. Sentences must already be no longer than size.
+func pack(sentences []string, size, overlap int, measure func(string) int) [][]string {
+	var (
+		chunks  [][]string
+		cur     []string
+		curSize int
+	)
+	for _, s := range sentences {
+		n := measure(s)
+		if curSize+n > size && len(cur) > 0 {
+			chunks = append(chunks, cur)
+			cur, curSize = overlapTail(cur, overlap, measure)
+			for curSize+n > size && len(cur) > 0 {
+				curSize -= measure(cur[0])
+				cur = cur[1:]
+			}
+		}
+		cur = append(cur, s)
+		curSize += n
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+func overlapTail(chunk []string, overlap int, measure func(string) int) ([]string, int) {
+	size, start := 0, len(chunk)
+	for start > 0 {
+		n := measure(chunk[start-1])
+		if size+n > overlap {
+			break
+		}
+		size += n
+		start--
+	}
+	tail := make([]string, len(chunk)-start)
+	copy(tail, chunk[start:])
+	return tail, size
+}
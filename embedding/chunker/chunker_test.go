@@ -0,0 +1,138 @@
+package chunker
+
+import (
+	schema "sea/embedding/schema/vector"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 中英文句末标点都能切开，拼回去和原文一致
+func TestSplitSentences(t *testing.T) {
+	text := "第一句。第二句！“引号里？”Then English. Pi is 3.14 here\nnext line"
+	got := splitSentences(text)
+	assert.Equal(t, []string{"第一句。", "第二句！", "“引号里？”", "Then English. ", "Pi is 3.14 here\n", "next line"}, got)
+	assert.Equal(t, text, strings.Join(got, ""))
+}
+
+// 按标题切段，代码块里的 # 不算标题
+func TestSplitSections(t *testing.T) {
+	body := "intro\n# A\ntext a\n```\n# not heading\n```\n## B\ntext b\n# C\ntext c\n"
+	secs := splitSections(body)
+	require.Len(t, secs, 4)
+	assert.Equal(t, "", secs[0].Heading)
+	assert.Equal(t, "A", secs[1].Heading)
+	assert.Contains(t, secs[1].Text, "# not heading")
+	assert.Equal(t, "A > B", secs[2].Heading)
+	assert.Equal(t, "C", secs[3].Heading)
+}
+
+// 分块不超过大小，重叠按整句保留
+func TestPackOverlap(t *testing.T) {
+	measure := measureFunc(UnitChar)
+	sentences := []string{"aaaa", "bbbb", "cccc", "dddd"}
+	got := pack(sentences, 8, 4, measure)
+	assert.Equal(t, [][]string{{"aaaa", "bbbb"}, {"bbbb", "cccc"}, {"cccc", "dddd"}}, got)
+
+	got = pack(sentences, 8, 0, measure)
+	assert.Equal(t, [][]string{{"aaaa", "bbbb"}, {"cccc", "dddd"}}, got)
+}
+
+// token 模式下中文按字、英文按词计数
+func TestMeasureToken(t *testing.T) {
+	measure := measureFunc(UnitToken)
+	assert.Equal(t, 4, measure("向量检索"))
+	assert.Equal(t, 3, measure("hello world 42"))
+	assert.Equal(t, 5, measure("用Milvus做召回"))
+}
+
+// 超长句子被硬切
+func TestSplitLong(t *testing.T) {
+	got := splitLong([]string{strings.Repeat("字", 25)}, 10, UnitChar)
+	require.Len(t, got, 3)
+	assert.Equal(t, 10, len([]rune(got[0])))
+	assert.Equal(t, 5, len([]rune(got[2])))
+}
+
+func testArticle() Article {
+	return Article{
+		ArticleID: "a1",
+		Title:     "向量召回",
+		Tags:      []string{"", "ai", "search"},
+		Body: "# 背景\n" + strings.Repeat("向量检索很常用。", 30) +
+			"\n# 方案\n" + strings.Repeat("先粗召回再精排。", 30),
+	}
+}
+
+// 父块不跨标题，子块不超过大小并挂在父块下
+func TestSplitArticle(t *testing.T) {
+	opts := Options{ParentSize: 100, ParentOverlap: 10, ChildSize: 30, ChildOverlap: 8}
+	res, err := Split(testArticle(), opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Parents)
+	require.NotEmpty(t, res.Children)
+
+	parents := make(map[string]ParentChunk)
+	for _, p := range res.Parents {
+		parents[p.Node.NodeID] = p
+		assert.LessOrEqual(t, len([]rune(p.Text)), 100)
+		assert.False(t, strings.Contains(p.Text, "背景") && strings.Contains(p.Text, "方案"), p.Text)
+		assert.Equal(t, "ai", p.Node.Tag)
+		assert.Equal(t, "a1", p.Node.ArticleID)
+	}
+	for _, c := range res.Children {
+		assert.LessOrEqual(t, len([]rune(c.Text)), 30)
+		p, ok := parents[c.Node.ParentNodeID]
+		require.True(t, ok)
+		assert.Contains(t, p.Text, c.Text)
+		assert.Equal(t, p.Heading, c.Heading)
+		assert.True(t, strings.HasPrefix(c.Node.ChunkID, p.Node.ChunkID+"#c"))
+	}
+	assert.Equal(t, "a1#p0000", res.Parents[0].Node.ChunkID)
+	assert.Equal(t, "方案", res.Parents[len(res.Parents)-1].Heading)
+	assert.Len(t, res.ParentNodes(), len(res.Parents))
+	assert.Len(t, res.ChildNodes(), len(res.Children))
+}
+
+// 同一篇文章重复切分得到相同的 ChunkID
+func TestSplitStableIDs(t *testing.T) {
+	a, err := Split(testArticle(), DefaultOptions)
+	require.NoError(t, err)
+	b, err := Split(testArticle(), DefaultOptions)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+// 参数和输入校验
+func TestSplitInvalid(t *testing.T) {
+	_, err := Split(Article{ArticleID: "a1", Body: " \n"}, DefaultOptions)
+	assert.ErrorIs(t, err, ErrEmptyArticle)
+
+	_, err = Split(Article{ArticleID: "a#1", Body: "x"}, DefaultOptions)
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	// 长度按字节算，40 个汉字就是 120 字节
+	_, err = Split(Article{ArticleID: strings.Repeat("文", 40), Body: "x"}, DefaultOptions)
+	assert.ErrorIs(t, err, ErrInvalidID)
+	res, err := Split(Article{ArticleID: strings.Repeat("文", 38), Body: "x"}, DefaultOptions)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(res.Children[0].Node.ChunkID), schema.IDMaxLength)
+
+	// 父块或子块超过 9999 个时 ID 会变宽，直接拒绝
+	opts := Options{ParentSize: 2, ChildSize: 2}
+	_, err = Split(Article{ArticleID: "a1", Body: strings.Repeat("一。", MaxChunks)}, opts)
+	require.NoError(t, err)
+	_, err = Split(Article{ArticleID: "a1", Body: strings.Repeat("一。", MaxChunks+1)}, opts)
+	assert.ErrorIs(t, err, ErrTooManyChunks)
+	opts = Options{ParentSize: 2 * (MaxChunks + 1), ChildSize: 2}
+	_, err = Split(Article{ArticleID: "a1", Body: strings.Repeat("一。", MaxChunks+1)}, opts)
+	assert.ErrorIs(t, err, ErrTooManyChunks)
+
+	_, err = Split(Article{ArticleID: "a1", Body: "x"}, Options{ParentSize: 10, ChildSize: 20})
+	assert.Error(t, err)
+
+	_, err = Split(Article{ArticleID: "a1", Body: "x"}, Options{Unit: "byte"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.+?)[ \t#]*$`)
+
+// section is the text under one markdown heading, heading line included
+type section struct {
+	Heading string
+	Text    string
+}
+
+// splitSections cuts markdown at ATX headings, ignoring "#" lines inside
+// fenced code blocks. Heading is the path of enclosing headings.
+func splitSections(body string) []section {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	var (
+		sections []section
+		path     []string
+		levels   []int
+		buf      strings.Builder
+		fence    string
+	)
+	flush := func() {
+		if strings.TrimSpace(buf.String()) != "" {
+			sections = append(sections, section{Heading: strings.Join(path, " > "), Text: buf.String()})
+		}
+		buf.Reset()
+	}
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			buf.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			buf.WriteString(line)
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil {
+			flush()
+			level := len(m[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+				path = path[:len(path)-1]
+			}
+			levels = append(levels, level)
+			path = append(path, m[2])
+		}
+		buf.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// splitSentences cuts after sentence final punctuation (CJK and latin) and
+// after line breaks. Every piece keeps its trailing whitespace so joining
+// the pieces gives back the input.
+func splitSentences(text string) []string {
+	runes := []rune(text)
+	var (
+		out   []string
+		start int
+	)
+	cut := func(end int) {
+		// 把后面的空白也带上
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		if end > start {
+			out = append(out, string(runes[start:end]))
+		}
+		start = end
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			cut(i + 1)
+			i = start - 1
+		case isTerminator(r):
+			j := i + 1
+			for j < len(runes) && (isTerminator(runes[j]) || isCloser(runes[j])) {
+				j++
+			}
+			cut(j)
+			i = start - 1
+		case r == '.':
+			// 英文句号后面跟空白才算句末，3.14 和 e.g 不拆
+			j := i + 1
+			for j < len(runes) && isCloser(runes[j]) {
+				j++
+			}
+			if j == len(runes) || unicode.IsSpace(runes[j]) {
+				cut(j)
+				i = start - 1
+			}
+		}
+	}
+	if start < len(runes) {
+		out = append(out, string(runes[start:]))
+	}
+	// 只有空白的片段并到前一句
+	merged := out[:0]
+	for _, s := range out {
+		if strings.TrimSpace(s) == "" && len(merged) > 0 {
+			merged[len(merged)-1] += s
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func isTerminator(r rune) bool {
+	switch r {
+	case '。', '！', '？', '；', '!', '?', ';', '…':
+		return true
+	}
+	return false
+}
+
+func isCloser(r rune) bool {
+	switch r {
+	case '"', '\'', ')', ']', '”', '’', '）', '】', '」', '』', '》':
+		return true
+	}
+	return false
+}
+
+// splitLong hard splits sentences longer than size so pack always fits
+func splitLong(sentences []string, size int, unit string) []string {
+	cost := runeCostFunc(unit)
+	var out []string
+	for _, s := range sentences {
+		var (
+			n     int
+			prev  rune
+			piece []rune
+		)
+		for _, r := range s {
+			c := cost(prev, r)
+			if n+c > size && len(piece) > 0 {
+				out = append(out, string(piece))
+				piece, n, prev = piece[:0:0], 0, 0
+				c = cost(prev, r)
+			}
+			piece = append(piece, r)
+			n += c
+			prev = r
+		}
+		if len(piece) > 0 {
+			out = append(out, string(piece))
+		}
+	}
+	return out
+}
+
+func measureFunc(unit string) func(string) int {
+	cost := runeCostFunc(unit)
+	return func(s string) int {
+		n := 0
+		var prev rune
+		for _, r := range s {
+			n += cost(prev, r)
+			prev = r
+		}
+		return n
+	}
+}
+
+// runeCostFunc returns the size contribution of r following prev. In token
+// mode every CJK character and every latin word or number counts as one.
+func runeCostFunc(unit string) func(prev, r rune) int {
+	if unit != UnitToken {
+		return func(prev, r rune) int { return 1 }
+	}
+	return func(prev, r rune) int {
+		switch {
+		case isCJK(r):
+			return 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if prev != 0 && !isCJK(prev) && (unicode.IsLetter(prev) || unicode.IsDigit(prev)) {
+				return 0
+			}
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
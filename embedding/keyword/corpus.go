@@ -0,0 +1,295 @@
+package keyword
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Corpus holds document frequencies for IDF and the keywords of every
+// document for edge building. A document is one child chunk keyed by its
+// node id; documents are grouped by article so a re-ingest replaces them.
+// A Corpus is local to the process, so IDF and keyword edges are only
+// consistent when a single instance ingests articles.
+type Corpus struct {
+	mu       sync.RWMutex
+	df       map[string]int
+	terms    map[string][]string // doc -> distinct terms
+	keywords map[string][]string // doc -> keywords
+	postings map[string]map[string]struct{}
+	articles map[string][]string // article -> docs
+	version  uint64              // bumped on every change
+}
+
+func NewCorpus() *Corpus {
+	return &Corpus{
+		df:       make(map[string]int),
+		terms:    make(map[string][]string),
+		keywords: make(map[string][]string),
+		postings: make(map[string]map[string]struct{}),
+		articles: make(map[string][]string),
+	}
+}
+
+// Document is one entry of an article
+type Document struct {
+	ID       string
+	Terms    []string
+	Keywords []string
+}
+
+// ReplaceArticle drops the previous documents of the article and adds docs
+func (c *Corpus) ReplaceArticle(articleID string, docs []Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.articles[articleID] {
+		c.removeLocked(id)
+	}
+	ids := make([]string, 0, len(docs))
+	for _, d := range docs {
+		c.removeLocked(d.ID)
+		c.addLocked(d)
+		ids = append(ids, d.ID)
+	}
+	c.version++
+	if len(ids) == 0 {
+		delete(c.articles, articleID)
+		return
+	}
+	c.articles[articleID] = ids
+}
+
+// PreviewIDF returns the IDF the corpus would have after
+// ReplaceArticle(articleID, docs), without changing it
+func (c *Corpus) PreviewIDF(articleID string, docs []Document) func(term string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	delta := make(map[string]int)
+	n := len(c.terms)
+	replaced := make(map[string]bool)
+	for _, id := range c.articles[articleID] {
+		replaced[id] = true
+	}
+	for _, d := range docs {
+		if _, ok := c.terms[d.ID]; ok {
+			replaced[d.ID] = true
+		}
+	}
+	for id := range replaced {
+		n--
+		for _, t := range c.terms[id] {
+			delta[t]--
+		}
+	}
+	for _, d := range docs {
+		n++
+		for _, t := range distinct(d.Terms) {
+			delta[t]++
+		}
+	}
+	// the article's own terms are fixed now; any other term is unaffected
+	// by the replacement and read live
+	df := make(map[string]int, len(delta))
+	for t, d := range delta {
+		df[t] = max(c.df[t]+d, 0)
+	}
+	return func(term string) float64 {
+		if v, ok := df[term]; ok {
+			return idf(n, v)
+		}
+		c.mu.RLock()
+		v := c.df[term]
+		c.mu.RUnlock()
+		return idf(n, v)
+	}
+}
+
+// Version changes whenever the corpus does
+func (c *Corpus) Version() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+func (c *Corpus) addLocked(d Document) {
+	terms := distinct(d.Terms)
+	c.terms[d.ID] = terms
+	for _, t := range terms {
+		c.df[t]++
+	}
+	kws := distinct(d.Keywords)
+	c.keywords[d.ID] = kws
+	for _, k := range kws {
+		p := c.postings[k]
+		if p == nil {
+			p = make(map[string]struct{})
+			c.postings[k] = p
+		}
+		p[d.ID] = struct{}{}
+	}
+}
+
+func (c *Corpus) removeLocked(id string) {
+	for _, t := range c.terms[id] {
+		if c.df[t]--; c.df[t] <= 0 {
+			delete(c.df, t)
+		}
+	}
+	for _, k := range c.keywords[id] {
+		if p := c.postings[k]; p != nil {
+			delete(p, id)
+			if len(p) == 0 {
+				delete(c.postings, k)
+			}
+		}
+	}
+	delete(c.terms, id)
+	delete(c.keywords, id)
+}
+
+// Size is the number of documents
+func (c *Corpus) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.terms)
+}
+
+// IDF is the smoothed inverse document frequency ln((N+1)/(df+1)) + 1,
+// so unseen terms score highest and no term scores zero
+func (c *Corpus) IDF(term string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.idfLocked(term)
+}
+
+func (c *Corpus) idfLocked(term string) float64 {
+	return idf(len(c.terms), c.df[term])
+}
+
+func idf(n, df int) float64 {
+	return math.Log(float64(n+1)/float64(df+1)) + 1
+}
+
+// Keywords returns the stored keywords of a document
+func (c *Corpus) Keywords(id string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keywords[id]
+}
+
+// Documents returns the ids of every document
+func (c *Corpus) Documents() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ids := make([]string, 0, len(c.terms))
+	for id := range c.terms {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// sharing returns the documents other than id that share a keyword with
+// it, skipping keywords found in more than maxDF documents
+func (c *Corpus) sharing(id string, maxDF int) map[string]struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]struct{})
+	for _, k := range c.keywords[id] {
+		p := c.postings[k]
+		if maxDF > 0 && len(p) > maxDF {
+			continue
+		}
+		for other := range p {
+			if other != id {
+				out[other] = struct{}{}
+			}
+		}
+	}
+	return out
+}
+
+// snapshot is the persisted form; df and postings are rebuilt on load
+type snapshot struct {
+	Articles map[string][]Document `json:"articles"`
+}
+
+// Save writes the corpus as JSON
+func (c *Corpus) Save(w io.Writer) error {
+	c.mu.RLock()
+	snap := snapshot{Articles: make(map[string][]Document, len(c.articles))}
+	for article, ids := range c.articles {
+		docs := make([]Document, len(ids))
+		for i, id := range ids {
+			docs[i] = Document{ID: id, Terms: c.terms[id], Keywords: c.keywords[id]}
+		}
+		snap.Articles[article] = docs
+	}
+	c.mu.RUnlock()
+	return json.NewEncoder(w).Encode(snap)
+}
+
+// Load replaces the corpus with one written by Save
+func (c *Corpus) Load(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return err
+	}
+	fresh := NewCorpus()
+	for article, docs := range snap.Articles {
+		fresh.ReplaceArticle(article, docs)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.df, c.terms, c.keywords, c.postings, c.articles = fresh.df, fresh.terms, fresh.keywords, fresh.postings, fresh.articles
+	c.version++
+	return nil
+}
+
+// SaveFile writes the corpus atomically through a temp file
+func (c *Corpus) SaveFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := c.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile loads path; a missing file leaves the corpus empty
+func (c *Corpus) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Load(f)
+}
+
+func distinct(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
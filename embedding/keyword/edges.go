@@ -0,0 +1,189 @@
+package keyword
+
+import (
+	"context"
+	"sea/embedding/schema/graph"
+	"sort"
+)
+
+// Keyword edges are stored once per pair, from the smaller node id to the
+// larger one; graph expansion follows them in both directions
+const (
+	RelSharesKeyword = "SHARES_KEYWORD"
+	EdgeTag          = "keyword"
+
+	defaultMinWeight       = 0.1
+	defaultMaxEdgesPerNode = 10
+	defaultMaxDocFreqRatio = 0.2
+	// minDocsForDFCap keeps the document frequency cap off in tiny corpora
+	minDocsForDFCap = 20
+	rebuildBatch    = 500
+)
+
+// EdgeStore is the part of graph.Repository used by the edge job
+type EdgeStore interface {
+	UpsertEdges(ctx context.Context, edges []graph.Edge) error
+	DeleteEdges(ctx context.Context, nodeIDs []string, relType string) (int64, error)
+	DeleteEdgesOfType(ctx context.Context, relType string) (int64, error)
+}
+
+type EdgeOptions struct {
+	// MinWeight drops weaker pairs
+	MinWeight float64
+	// MaxEdgesPerNode keeps the strongest edges of each rebuilt node
+	MaxEdgesPerNode int
+	// MaxDocFreqRatio ignores keywords found in more than this share of
+	// documents; they link everything to everything
+	MaxDocFreqRatio float64
+}
+
+// EdgeJob links documents that share keywords, weighted by IDF-weighted
+// Jaccard overlap: sum idf(shared) / sum idf(union)
+type EdgeJob struct {
+	corpus *Corpus
+	store  EdgeStore
+	opts   EdgeOptions
+}
+
+func NewEdgeJob(corpus *Corpus, store EdgeStore, opts EdgeOptions) *EdgeJob {
+	if opts.MinWeight <= 0 {
+		opts.MinWeight = defaultMinWeight
+	}
+	if opts.MaxEdgesPerNode <= 0 {
+		opts.MaxEdgesPerNode = defaultMaxEdgesPerNode
+	}
+	if opts.MaxDocFreqRatio <= 0 {
+		opts.MaxDocFreqRatio = defaultMaxDocFreqRatio
+	}
+	return &EdgeJob{corpus: corpus, store: store, opts: opts}
+}
+
+// BuildFor replaces the keyword edges of the given documents and returns
+// the number of edges written. Call it after new chunks were ingested.
+func (j *EdgeJob) BuildFor(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if _, err := j.store.DeleteEdges(ctx, ids, RelSharesKeyword); err != nil {
+		return 0, err
+	}
+	edges := j.edgesFor(ids)
+	if len(edges) == 0 {
+		return 0, nil
+	}
+	return len(edges), j.store.UpsertEdges(ctx, edges)
+}
+
+// Rebuild recomputes the keyword edges of the whole corpus and returns the
+// number of edge writes; a pair found from both ends counts twice. All
+// keyword edges are dropped once up front; deleting per batch would also
+// drop the edges earlier batches wrote to nodes of the current one.
+func (j *EdgeJob) Rebuild(ctx context.Context) (int, error) {
+	if _, err := j.store.DeleteEdgesOfType(ctx, RelSharesKeyword); err != nil {
+		return 0, err
+	}
+	ids := j.corpus.Documents()
+	sort.Strings(ids)
+	total := 0
+	for start := 0; start < len(ids); start += rebuildBatch {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		edges := j.edgesFor(ids[start:min(start+rebuildBatch, len(ids))])
+		if len(edges) == 0 {
+			continue
+		}
+		if err := j.store.UpsertEdges(ctx, edges); err != nil {
+			return total, err
+		}
+		total += len(edges)
+	}
+	return total, nil
+}
+
+func (j *EdgeJob) edgesFor(ids []string) []graph.Edge {
+	maxDF := 0
+	if n := j.corpus.Size(); n >= minDocsForDFCap {
+		maxDF = int(j.opts.MaxDocFreqRatio * float64(n))
+	}
+
+	byID := make(map[string]graph.Edge)
+	for _, id := range ids {
+		mine := j.corpus.Keywords(id)
+		var scored []graph.Edge
+		for other := range j.corpus.sharing(id, maxDF) {
+			w := j.Weight(mine, j.corpus.Keywords(other))
+			if w < j.opts.MinWeight {
+				continue
+			}
+			scored = append(scored, keywordEdge(id, other, w))
+		}
+		sort.Slice(scored, func(a, b int) bool {
+			if scored[a].Weight != scored[b].Weight {
+				return scored[a].Weight > scored[b].Weight
+			}
+			return scored[a].EdgeID < scored[b].EdgeID
+		})
+		if len(scored) > j.opts.MaxEdgesPerNode {
+			scored = scored[:j.opts.MaxEdgesPerNode]
+		}
+		for _, e := range scored {
+			byID[e.EdgeID] = e
+		}
+	}
+
+	edges := make([]graph.Edge, 0, len(byID))
+	for _, e := range byID {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(a, b int) bool { return edges[a].EdgeID < edges[b].EdgeID })
+	return edges
+}
+
+// Weight is the IDF-weighted Jaccard overlap of two keyword sets
+func (j *EdgeJob) Weight(a, b []string) float64 {
+	inA := make(map[string]bool, len(a))
+	for _, k := range a {
+		inA[k] = true
+	}
+	var shared, union float64
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, k := range append(append([]string(nil), a...), b...) {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		idf := j.corpus.IDF(k)
+		union += idf
+		if inA[k] && contains(b, k) {
+			shared += idf
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return shared / union
+}
+
+func keywordEdge(a, b string, weight float64) graph.Edge {
+	if b < a {
+		a, b = b, a
+	}
+	return graph.Edge{
+		EdgeID:     "kw:" + a + "|" + b,
+		FromNodeID: a,
+		ToNodeID:   b,
+		Type:       RelSharesKeyword,
+		Weight:     weight,
+		Tag:        EdgeTag,
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package keyword
+
+import (
+	"sea/embedding/chunker"
+	"sort"
+)
+
+const defaultTopN = 8
+
+// Extractor picks TF-IDF keywords for chunks and records them in a corpus
+type Extractor struct {
+	corpus    *Corpus
+	tokenizer *Tokenizer
+	topN      int
+}
+
+func NewExtractor(corpus *Corpus, topN int) *Extractor {
+	if topN <= 0 {
+		topN = defaultTopN
+	}
+	return &Extractor{corpus: corpus, tokenizer: plain, topN: topN}
+}
+
+// WithTokenizer replaces the dictionary free tokenizer
+func (x *Extractor) WithTokenizer(t *Tokenizer) *Extractor {
+	x.tokenizer = t
+	return x
+}
+
+// Annotate fills the Keywords of every child and parent in res, with IDF
+// computed as if the article's chunks were already in the corpus. The
+// corpus itself is left alone until the returned commit runs, which the
+// caller does once the chunks are stored.
+func (x *Extractor) Annotate(res *chunker.Result) (commit func()) {
+	if len(res.Parents) == 0 {
+		return func() {}
+	}
+	articleID := res.Parents[0].Node.ArticleID
+
+	terms := make([][]string, len(res.Children))
+	docs := make([]Document, len(res.Children))
+	for i, c := range res.Children {
+		terms[i] = x.tokenizer.Tokenize(titled(c.Heading, c.Text))
+		docs[i] = Document{ID: c.Node.NodeID, Terms: terms[i]}
+	}
+	idf := x.corpus.PreviewIDF(articleID, docs)
+
+	for i := range res.Children {
+		kws := top(terms[i], idf, x.topN)
+		res.Children[i].Node.Keywords = kws
+		docs[i].Keywords = kws
+	}
+	for i, p := range res.Parents {
+		res.Parents[i].Node.Keywords = top(x.tokenizer.Tokenize(titled(p.Heading, p.Text)), idf, x.topN)
+	}
+	return func() { x.corpus.ReplaceArticle(articleID, docs) }
+}
+
+// Top returns the topN terms by tf*idf, ties broken alphabetically
+func (x *Extractor) Top(terms []string) []string {
+	return top(terms, x.corpus.IDF, x.topN)
+}
+
+func top(terms []string, idf func(string) float64, n int) []string {
+	tf := make(map[string]int, len(terms))
+	for _, t := range terms {
+		tf[t]++
+	}
+	type scored struct {
+		term  string
+		score float64
+	}
+	list := make([]scored, 0, len(tf))
+	for t, c := range tf {
+		list = append(list, scored{term: t, score: float64(c) * idf(t)})
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].score != list[b].score {
+			return list[a].score > list[b].score
+		}
+		return list[a].term < list[b].term
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = s.term
+	}
+	return out
+}
+
+// titled lets heading words count towards the chunk's keywords
+func titled(heading, text string) string {
+	if heading == "" {
+		return text
+	}
+	return heading + "\n" + text
+}
@@ -0,0 +1,132 @@
+// Package keyword extracts TF-IDF keywords from chunks and links chunks
+// that share keywords in the graph.
+package keyword
+
+import (
+	"sea/config"
+	"sea/zlog"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultFlushInterval = 30 * time.Second
+
+var (
+	defaultMu     sync.RWMutex
+	defaultCorpus = NewCorpus()
+	tokenizer     = plain
+	corpusPath    string
+	// savedVersion is the corpus version last written to corpusPath
+	savedVersion uint64
+)
+
+// Init loads the process wide corpus from cfg.CorpusPath and the segmenter
+// dictionary from cfg.DictionaryPath when set. Without a corpus path the
+// corpus lives in memory and IDF restarts from zero.
+//
+// The corpus file belongs to one process: instances sharing it would
+// overwrite each other's document frequencies, so run a single ingesting
+// instance per corpus.
+func Init(cfg config.KeywordConfig) error {
+	c := NewCorpus()
+	if cfg.CorpusPath != "" {
+		if err := c.LoadFile(cfg.CorpusPath); err != nil {
+			return err
+		}
+	}
+	t := plain
+	if cfg.DictionaryPath != "" {
+		words, err := LoadDictionary(cfg.DictionaryPath)
+		if err != nil {
+			return err
+		}
+		t = NewTokenizer(words)
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCorpus = c
+	tokenizer = t
+	corpusPath = cfg.CorpusPath
+	savedVersion = c.Version()
+	return nil
+}
+
+// Flush saves the corpus to the path given to Init if it changed since the
+// last save
+func Flush() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if corpusPath == "" {
+		return nil
+	}
+	v := defaultCorpus.Version()
+	if v == savedVersion {
+		return nil
+	}
+	if err := defaultCorpus.SaveFile(corpusPath); err != nil {
+		return err
+	}
+	savedVersion = v
+	return nil
+}
+
+// StartFlusher calls Flush every interval so a crash loses at most one
+// interval of corpus updates. stop ends the loop and waits for it.
+func StartFlusher(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := Flush(); err != nil {
+					zlog.L().Error("keyword corpus flush failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
+
+// Close saves the corpus to the path given to Init
+func Close() error {
+	return Flush()
+}
+
+// Default returns the process wide corpus
+func Default() *Corpus {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultCorpus
+}
+
+// NewExtractorFromConfig and NewEdgeJobFromConfig build the pipeline parts
+// on the default corpus
+func NewExtractorFromConfig(cfg config.KeywordConfig) *Extractor {
+	defaultMu.RLock()
+	t := tokenizer
+	defaultMu.RUnlock()
+	return NewExtractor(Default(), cfg.TopN).WithTokenizer(t)
+}
+
+func NewEdgeJobFromConfig(cfg config.KeywordConfig, store EdgeStore) *EdgeJob {
+	return NewEdgeJob(Default(), store, EdgeOptions{
+		MinWeight:       cfg.MinWeight,
+		MaxEdgesPerNode: cfg.MaxEdgesPerNode,
+		MaxDocFreqRatio: cfg.MaxDocFreqRatio,
+	})
+}
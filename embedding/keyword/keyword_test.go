@@ -0,0 +1,245 @@
+package keyword
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sea/config"
+	"sea/embedding/chunker"
+	"sea/embedding/schema/graph"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memEdges 内存版边存储
+type memEdges struct {
+	edges   map[string]graph.Edge
+	deleted [][]string
+}
+
+func newMemEdges() *memEdges {
+	return &memEdges{edges: make(map[string]graph.Edge)}
+}
+
+func (m *memEdges) UpsertEdges(ctx context.Context, edges []graph.Edge) error {
+	for _, e := range edges {
+		m.edges[e.EdgeID] = e
+	}
+	return nil
+}
+
+func (m *memEdges) DeleteEdges(ctx context.Context, nodeIDs []string, relType string) (int64, error) {
+	m.deleted = append(m.deleted, nodeIDs)
+	var n int64
+	for id, e := range m.edges {
+		if e.Type == relType && (contains(nodeIDs, e.FromNodeID) || contains(nodeIDs, e.ToNodeID)) {
+			delete(m.edges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memEdges) DeleteEdgesOfType(ctx context.Context, relType string) (int64, error) {
+	var n int64
+	for id, e := range m.edges {
+		if e.Type == relType {
+			delete(m.edges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"milvus", "vector", "search"}, Tokenize("The Milvus vector-search of 2024"))
+	// 中文按二元组切分，停用字断开
+	assert.Equal(t, []string{"向量", "量检", "检索"}, Tokenize("向量检索"))
+	assert.Equal(t, []string{"向量", "检索"}, Tokenize("向量的检索"))
+	assert.Empty(t, Tokenize("  ,. 123 a"))
+	// 常见虚词组成的二元组被丢弃
+	assert.Equal(t, []string{"检索"}, Tokenize("但是检索"))
+}
+
+// 有词典时按最大正向匹配分词，不产生跨词的二元组
+func TestTokenizerDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 注释\n向量 100 n\n检索 80 v\n数据库 50 n\n向量数据库 10 n\n我们 90 r\n"), 0o644))
+	words, err := LoadDictionary(path)
+	require.NoError(t, err)
+	assert.Len(t, words, 5)
+
+	tk := NewTokenizer(words)
+	assert.Equal(t, []string{"向量", "检索"}, tk.Tokenize("向量检索"))
+	assert.Equal(t, []string{"向量数据库", "检索", "milvus"}, tk.Tokenize("我们的向量数据库检索 Milvus"))
+	assert.Empty(t, tk.Tokenize("未登录"))
+}
+
+func TestCorpusReplaceArticle(t *testing.T) {
+	c := NewCorpus()
+	c.ReplaceArticle("a1", []Document{
+		{ID: "a1#c0", Terms: []string{"milvus", "vector"}},
+		{ID: "a1#c1", Terms: []string{"milvus", "graph"}},
+	})
+	c.ReplaceArticle("a2", []Document{{ID: "a2#c0", Terms: []string{"graph"}}})
+	assert.Equal(t, 3, c.Size())
+	assert.Less(t, c.IDF("milvus"), c.IDF("vector"))
+	assert.Greater(t, c.IDF("unknown"), c.IDF("vector"))
+
+	// 重新导入会替换旧文档
+	c.ReplaceArticle("a1", []Document{{ID: "a1#c0", Terms: []string{"vector"}}})
+	assert.Equal(t, 2, c.Size())
+	assert.Equal(t, c.IDF("vector"), c.IDF("graph"))
+	assert.Equal(t, c.IDF("unknown"), c.IDF("milvus"))
+
+	c.ReplaceArticle("a1", nil)
+	assert.Equal(t, 1, c.Size())
+	assert.ElementsMatch(t, []string{"a2#c0"}, c.Documents())
+}
+
+func TestCorpusSaveLoad(t *testing.T) {
+	c := NewCorpus()
+	c.ReplaceArticle("a1", []Document{{ID: "a1#c0", Terms: []string{"milvus"}, Keywords: []string{"milvus"}}})
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Save(&buf))
+	loaded := NewCorpus()
+	require.NoError(t, loaded.Load(&buf))
+	assert.Equal(t, 1, loaded.Size())
+	assert.Equal(t, []string{"milvus"}, loaded.Keywords("a1#c0"))
+	assert.Equal(t, c.IDF("milvus"), loaded.IDF("milvus"))
+
+	path := filepath.Join(t.TempDir(), "corpus.json")
+	// 文件不存在时是空语料
+	require.NoError(t, NewCorpus().LoadFile(path))
+	require.NoError(t, c.SaveFile(path))
+	fromFile := NewCorpus()
+	require.NoError(t, fromFile.LoadFile(path))
+	assert.Equal(t, 1, fromFile.Size())
+}
+
+func TestAnnotate(t *testing.T) {
+	c := NewCorpus()
+	c.ReplaceArticle("other", []Document{{ID: "other#c0", Terms: []string{"search"}}})
+	res, err := chunker.Split(chunker.Article{
+		ArticleID: "a1",
+		Body:      "# Milvus\n\nMilvus search engine. Milvus stores vectors.",
+	}, chunker.DefaultOptions)
+	require.NoError(t, err)
+
+	commit := NewExtractor(c, 2).Annotate(&res)
+	require.NotEmpty(t, res.Children)
+	kws := res.Children[0].Node.Keywords
+	assert.Len(t, kws, 2)
+	assert.Equal(t, "milvus", kws[0])
+	assert.NotEmpty(t, res.Parents[0].Node.Keywords)
+
+	// 提交之前语料不变
+	assert.Equal(t, 1, c.Size())
+	assert.Empty(t, c.Keywords(res.Children[0].Node.NodeID))
+	commit()
+	assert.Equal(t, 1+len(res.Children), c.Size())
+	assert.Equal(t, kws, c.Keywords(res.Children[0].Node.NodeID))
+}
+
+func TestPreviewIDF(t *testing.T) {
+	c := NewCorpus()
+	c.ReplaceArticle("a1", []Document{{ID: "a1#c0", Terms: []string{"milvus", "vector"}}})
+	c.ReplaceArticle("a2", []Document{{ID: "a2#c0", Terms: []string{"graph"}}})
+	docs := []Document{{ID: "a1#c0", Terms: []string{"graph"}}, {ID: "a1#c1", Terms: []string{"graph", "graph"}}}
+
+	preview := c.PreviewIDF("a1", docs)
+	before := c.Version()
+	c.ReplaceArticle("a1", docs)
+	assert.NotEqual(t, before, c.Version())
+	for _, term := range []string{"milvus", "vector", "graph", "unknown"} {
+		assert.InDelta(t, c.IDF(term), preview(term), 1e-9, term)
+	}
+}
+
+func TestEdgeJobBuildFor(t *testing.T) {
+	c := NewCorpus()
+	c.ReplaceArticle("a1", []Document{{ID: "a", Terms: []string{"milvus", "vector"}, Keywords: []string{"milvus", "vector"}}})
+	c.ReplaceArticle("a2", []Document{{ID: "b", Terms: []string{"milvus", "vector"}, Keywords: []string{"milvus", "vector"}}})
+	c.ReplaceArticle("a3", []Document{{ID: "c", Terms: []string{"milvus", "neo4j"}, Keywords: []string{"milvus", "neo4j"}}})
+	c.ReplaceArticle("a4", []Document{{ID: "d", Terms: []string{"kafka"}, Keywords: []string{"kafka"}}})
+
+	store := newMemEdges()
+	job := NewEdgeJob(c, store, EdgeOptions{MinWeight: 0.2})
+	n, err := job.BuildFor(context.Background(), []string{"b", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, len(store.edges), n)
+
+	ab, ok := store.edges["kw:a|b"]
+	require.True(t, ok)
+	assert.Equal(t, "a", ab.FromNodeID)
+	assert.InDelta(t, 1.0, ab.Weight, 1e-9)
+	assert.Equal(t, RelSharesKeyword, ab.Type)
+	assert.Equal(t, EdgeTag, ab.Tag)
+	ac, ok := store.edges["kw:a|c"]
+	require.True(t, ok)
+	assert.Less(t, ac.Weight, ab.Weight)
+	for id := range store.edges {
+		assert.NotContains(t, id, "d")
+	}
+
+	// 只保留最强的边
+	store = newMemEdges()
+	job = NewEdgeJob(c, store, EdgeOptions{MinWeight: 0.2, MaxEdgesPerNode: 1})
+	_, err = job.BuildFor(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	assert.Len(t, store.edges, 1)
+	assert.Contains(t, store.edges, "kw:a|b")
+	assert.Equal(t, [][]string{{"a"}}, store.deleted)
+}
+
+func TestEdgeJobRebuildKeepsEarlierBatches(t *testing.T) {
+	c := NewCorpus()
+	// 超过一个批次，b 在 a 之后的批次里
+	c.ReplaceArticle("a1", []Document{{ID: "a", Terms: []string{"milvus"}, Keywords: []string{"milvus"}}})
+	var fillers []Document
+	for i := 0; i < rebuildBatch; i++ {
+		id := fmt.Sprintf("f%04d", i)
+		fillers = append(fillers, Document{ID: id, Terms: []string{id}, Keywords: []string{id}})
+	}
+	c.ReplaceArticle("fill", fillers)
+	c.ReplaceArticle("a2", []Document{{ID: "zz", Terms: []string{"milvus"}, Keywords: []string{"milvus"}}})
+
+	store := newMemEdges()
+	store.edges["kw:old|stale"] = keywordEdge("old", "stale", 1)
+	_, err := NewEdgeJob(c, store, EdgeOptions{}).Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, store.edges, 1)
+	assert.Contains(t, store.edges, "kw:a|zz")
+	assert.NotContains(t, store.edges, "kw:old|stale")
+	assert.Empty(t, store.deleted)
+}
+
+func TestFlushSavesOnlyChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.json")
+	require.NoError(t, Init(config.KeywordConfig{CorpusPath: path}))
+	t.Cleanup(func() { require.NoError(t, Init(config.KeywordConfig{})) })
+
+	// 没有变化不写文件
+	require.NoError(t, Flush())
+	assert.NoFileExists(t, path)
+
+	Default().ReplaceArticle("a1", []Document{{ID: "a1#c0", Terms: []string{"milvus"}}})
+	require.NoError(t, Flush())
+	loaded := NewCorpus()
+	require.NoError(t, loaded.LoadFile(path))
+	assert.Equal(t, 1, loaded.Size())
+
+	stop := StartFlusher(time.Millisecond)
+	Default().ReplaceArticle("a2", []Document{{ID: "a2#c0", Terms: []string{"graph"}}})
+	assert.Eventually(t, func() bool {
+		c := NewCorpus()
+		return c.LoadFile(path) == nil && c.Size() == 2
+	}, time.Second, 5*time.Millisecond)
+	stop()
+}
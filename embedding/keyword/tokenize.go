@@ -0,0 +1,191 @@
+package keyword
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer splits text into index terms. Latin words and numbers-with-
+// letters are lowercased whole words. Runs of CJK characters are segmented
+// by forward maximum matching against the dictionary, keeping only words of
+// two or more characters, so no term straddles a word boundary. Without a
+// dictionary a run falls back to overlapping bigrams (a single character run
+// stays a unigram); bigrams that touch a function character or are common
+// function words are dropped, but bigrams across two content words such as
+// 量检 in 向量检索 remain.
+type Tokenizer struct {
+	dict   map[string]bool
+	maxLen int // longest dictionary word in runes
+}
+
+// NewTokenizer builds a tokenizer on the given dictionary words; nil means
+// bigram fallback
+func NewTokenizer(words []string) *Tokenizer {
+	t := &Tokenizer{}
+	for _, w := range words {
+		n := utf8.RuneCountInString(w)
+		if n < 2 {
+			continue
+		}
+		if t.dict == nil {
+			t.dict = make(map[string]bool, len(words))
+		}
+		t.dict[w] = true
+		t.maxLen = max(t.maxLen, n)
+	}
+	return t
+}
+
+// LoadDictionary reads one word per line, taking the first field, so a
+// jieba style "word freq tag" dict.txt works as is. Blank lines and lines
+// starting with # are skipped.
+func LoadDictionary(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		words = append(words, fields[0])
+	}
+	return words, sc.Err()
+}
+
+var plain = NewTokenizer(nil)
+
+// Tokenize splits text with the dictionary free tokenizer
+func Tokenize(text string) []string {
+	return plain.Tokenize(text)
+}
+
+func (t *Tokenizer) Tokenize(text string) []string {
+	var (
+		terms []string
+		word  []rune
+		cjk   []rune
+	)
+	flushWord := func() {
+		if len(word) >= 2 {
+			w := strings.ToLower(string(word))
+			if !stopWords[w] && !isNumber(w) {
+				terms = append(terms, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if t.dict != nil {
+			terms = t.segment(terms, cjk)
+		} else {
+			terms = bigrams(terms, cjk)
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+// segment appends the dictionary words of run found by forward maximum
+// matching; characters not covered by a word are skipped
+func (t *Tokenizer) segment(terms []string, run []rune) []string {
+	for i := 0; i < len(run); {
+		n := 0
+		for l := min(t.maxLen, len(run)-i); l >= 2; l-- {
+			if t.dict[string(run[i:i+l])] {
+				n = l
+				break
+			}
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		if w := string(run[i : i+n]); !stopBigrams[w] {
+			terms = append(terms, w)
+		}
+		i += n
+	}
+	return terms
+}
+
+func bigrams(terms []string, run []rune) []string {
+	switch {
+	case len(run) == 1:
+		if !stopChars[run[0]] {
+			terms = append(terms, string(run))
+		}
+	case len(run) > 1:
+		for i := 0; i+1 < len(run); i++ {
+			if stopChars[run[i]] || stopChars[run[i+1]] {
+				continue
+			}
+			if w := string(run[i : i+2]); !stopBigrams[w] {
+				terms = append(terms, w)
+			}
+		}
+	}
+	return terms
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+var stopWords = toSet(strings.Fields(`
+a an and are as at be but by for from has have if in into is it its of on or
+that the their then there these this to was were will with we you your can not
+`))
+
+// stopChars are function characters that never start or end a useful bigram
+var stopChars = func() map[rune]bool {
+	m := make(map[rune]bool)
+	for _, r := range "的了是在和与及或也就都而被把对从这那个之其我你他她它们" {
+		m[r] = true
+	}
+	return m
+}()
+
+// stopBigrams are common function words made of otherwise useful characters
+var stopBigrams = toSet(strings.Fields(`
+可以 进行 通过 使用 以及 因为 所以 如果 但是 然后 并且 或者 没有 已经 还是
+其中 以下 以上 如何 什么 怎么 为了 由于 对于 关于 需要 可能 能够 时候 之后
+之前 同时 不同 主要 一个 一些 一种 一样 非常 比较 所有 每个 不会 不能 应该
+我们 你们 他们 它们 这个 那个 这些 那些 就是 而且 不是 只是 当前 目前 例如
+`))
+
+func toSet(words []string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
@@ -1 +1,143 @@
 package graph
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// The parent is matched, not merged, so a child whose parent is missing is
+// still written but left unlinked; write parents first.
+const upsertChildrenQuery = `UNWIND $rows AS row
+MERGE (n:Node {node_id: row.node_id})
+SET n:Child,
+    n.article_id = row.article_id,
+    n.parent_node_id = row.parent_node_id,
+    n.chunk_id = row.chunk_id,
+    n.title = row.title,
+    n.tag = row.tag,
+    n.keywords = row.keywords
+WITH n, row
+WHERE row.parent_node_id <> ''
+MATCH (p:Node {node_id: row.parent_node_id})
+MERGE (p)-[:HAS_CHILD]->(n)`
+
+// UpsertChildren creates or updates child nodes and their HAS_CHILD edges
+func (r *Repository) UpsertChildren(ctx context.Context, children []ChildNode) error {
+	rows := make([]map[string]any, len(children))
+	for i, c := range children {
+		rows[i] = childRow(c)
+	}
+	return r.writeBatches(ctx, upsertChildrenQuery, rows)
+}
+
+func (r *Repository) UpsertChild(ctx context.Context, child ChildNode) error {
+	return r.UpsertChildren(ctx, []ChildNode{child})
+}
+
+// GetChild returns ErrNotFound when no child has nodeID
+func (r *Repository) GetChild(ctx context.Context, nodeID string) (*ChildNode, error) {
+	return r.oneChild(ctx, `MATCH (n:Node:Child {node_id: $v}) RETURN n`, nodeID)
+}
+
+// ChildByChunkID returns ErrNotFound when no child has chunkID
+func (r *Repository) ChildByChunkID(ctx context.Context, chunkID string) (*ChildNode, error) {
+	return r.oneChild(ctx, `MATCH (n:Node:Child {chunk_id: $v}) RETURN n LIMIT 1`, chunkID)
+}
+
+// ChildrenByArticle returns the children of an article ordered by chunk id
+func (r *Repository) ChildrenByArticle(ctx context.Context, articleID string) ([]ChildNode, error) {
+	return r.children(ctx, `MATCH (n:Node:Child {article_id: $v}) RETURN n ORDER BY n.chunk_id`, articleID)
+}
+
+// ChildrenOfParent returns the children linked to a parent ordered by chunk id
+func (r *Repository) ChildrenOfParent(ctx context.Context, parentNodeID string) ([]ChildNode, error) {
+	return r.children(ctx,
+		`MATCH (:Node:Parent {node_id: $v})-[:HAS_CHILD]->(n:Node:Child) RETURN n ORDER BY n.chunk_id`, parentNodeID)
+}
+
+func (r *Repository) oneChild(ctx context.Context, query, value string) (*ChildNode, error) {
+	children, err := r.children(ctx, query, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) == 0 {
+		return nil, ErrNotFound
+	}
+	return &children[0], nil
+}
+
+func (r *Repository) children(ctx context.Context, query, value string) ([]ChildNode, error) {
+	res, err := r.read(ctx, query, map[string]any{"v": value})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := nodesFromResult(res)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ChildNode, len(nodes))
+	for i, n := range nodes {
+		out[i] = childFromNode(n)
+	}
+	return out, nil
+}
+
+// ChildParent pairs a child with the parent it hangs off
+type ChildParent struct {
+	Child  ChildNode
+	Parent ParentNode
+}
+
+// ParentsOfChildren follows HAS_CHILD backwards from the children with the
+// given chunk ids. Children without a parent are left out.
+func (r *Repository) ParentsOfChildren(ctx context.Context, chunkIDs []string) ([]ChildParent, error) {
+	if len(chunkIDs) == 0 {
+		return nil, nil
+	}
+	res, err := r.read(ctx,
+		`UNWIND $ids AS id
+MATCH (p:Node:Parent)-[:HAS_CHILD]->(c:Node:Child {chunk_id: id})
+RETURN c, p`,
+		map[string]any{"ids": chunkIDs})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ChildParent, 0, len(res.Records))
+	for _, rec := range res.Records {
+		c, _, err := neo4j.GetRecordValue[neo4j.Node](rec, "c")
+		if err != nil {
+			return nil, err
+		}
+		p, _, err := neo4j.GetRecordValue[neo4j.Node](rec, "p")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ChildParent{Child: childFromNode(c), Parent: parentFromNode(p)})
+	}
+	return out, nil
+}
+
+func childRow(c ChildNode) map[string]any {
+	return map[string]any{
+		"node_id":        c.NodeID,
+		"article_id":     c.ArticleID,
+		"parent_node_id": c.ParentNodeID,
+		"chunk_id":       c.ChunkID,
+		"title":          c.Title,
+		"tag":            c.Tag,
+		"keywords":       keywordsParam(c.Keywords),
+	}
+}
+
+func childFromNode(n neo4j.Node) ChildNode {
+	return ChildNode{
+		NodeID:       stringProp(n.Props, "node_id"),
+		ArticleID:    stringProp(n.Props, "article_id"),
+		ParentNodeID: stringProp(n.Props, "parent_node_id"),
+		ChunkID:      stringProp(n.Props, "chunk_id"),
+		Title:        stringProp(n.Props, "title"),
+		Tag:          stringProp(n.Props, "tag"),
+		Keywords:     stringsProp(n.Props, "keywords"),
+	}
+}
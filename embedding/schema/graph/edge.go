@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Relationship types cannot be query parameters, so they are validated and
+// spliced into the query text
+var relTypePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+const upsertEdgesQuery = `UNWIND $rows AS row
+MATCH (a:Node {node_id: row.from_node_id})
+MATCH (b:Node {node_id: row.to_node_id})
+MERGE (a)-[r:%s {edge_id: row.edge_id}]->(b)
+SET r.weight = row.weight,
+    r.tag = row.tag`
+
+// UpsertEdges creates or updates edges keyed by EdgeID. Edges whose
+// endpoints do not exist yet are skipped by the MATCH.
+func (r *Repository) UpsertEdges(ctx context.Context, edges []Edge) error {
+	byType := make(map[string][]map[string]any)
+	for _, e := range edges {
+		t, err := edgeType(e.Type)
+		if err != nil {
+			return err
+		}
+		byType[t] = append(byType[t], edgeRow(e))
+	}
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if err := r.writeBatches(ctx, fmt.Sprintf(upsertEdgesQuery, t), byType[t]); err != nil {
+			return fmt.Errorf("upsert %s edges: %w", t, err)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) UpsertEdge(ctx context.Context, edge Edge) error {
+	return r.UpsertEdges(ctx, []Edge{edge})
+}
+
+// DeleteEdges removes the edges of relType touching any of the nodes
+func (r *Repository) DeleteEdges(ctx context.Context, nodeIDs []string, relType string) (int64, error) {
+	t, err := edgeType(relType)
+	if err != nil {
+		return 0, err
+	}
+	if len(nodeIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.write(ctx, fmt.Sprintf(`UNWIND $ids AS id
+MATCH (:Node {node_id: id})-[r:%s]-()
+WITH DISTINCT r
+DELETE r
+RETURN count(r) AS deleted`, t), map[string]any{"ids": nodeIDs})
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Records) == 0 {
+		return 0, nil
+	}
+	deleted, _, err := neo4j.GetRecordValue[int64](res.Records[0], "deleted")
+	return deleted, err
+}
+
+// DeleteEdgesOfType removes every edge of relType, a batch per transaction
+// so large graphs do not build one huge transaction
+func (r *Repository) DeleteEdgesOfType(ctx context.Context, relType string) (int64, error) {
+	t, err := edgeType(relType)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`MATCH ()-[r:%s]->()
+WITH r LIMIT $limit
+DELETE r
+RETURN count(r) AS deleted`, t)
+	var total int64
+	for {
+		res, err := r.write(ctx, query, map[string]any{"limit": r.batchSize})
+		if err != nil {
+			return total, err
+		}
+		if len(res.Records) == 0 {
+			return total, nil
+		}
+		deleted, _, err := neo4j.GetRecordValue[int64](res.Records[0], "deleted")
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(r.batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// EdgesFrom returns the outgoing edges of a node except HAS_CHILD, highest
+// weight first
+func (r *Repository) EdgesFrom(ctx context.Context, nodeID string) ([]Edge, error) {
+	res, err := r.read(ctx,
+		`MATCH (a:Node {node_id: $v})-[r]->(b:Node)
+WHERE type(r) <> 'HAS_CHILD'
+RETURN r, a.node_id AS from_node_id, b.node_id AS to_node_id
+ORDER BY r.weight DESC`,
+		map[string]any{"v": nodeID})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Edge, 0, len(res.Records))
+	for _, rec := range res.Records {
+		rel, _, err := neo4j.GetRecordValue[neo4j.Relationship](rec, "r")
+		if err != nil {
+			return nil, err
+		}
+		from, _, _ := neo4j.GetRecordValue[string](rec, "from_node_id")
+		to, _, _ := neo4j.GetRecordValue[string](rec, "to_node_id")
+		weight, _ := rel.Props["weight"].(float64)
+		out = append(out, Edge{
+			EdgeID:     stringProp(rel.Props, "edge_id"),
+			FromNodeID: from,
+			ToNodeID:   to,
+			Type:       rel.Type,
+			Weight:     weight,
+			Tag:        stringProp(rel.Props, "tag"),
+		})
+	}
+	return out, nil
+}
+
+func edgeType(t string) (string, error) {
+	if t == "" {
+		return RelRelatedTo, nil
+	}
+	if !relTypePattern.MatchString(t) {
+		return "", fmt.Errorf("graph: invalid edge type %q", t)
+	}
+	return t, nil
+}
+
+func edgeRow(e Edge) map[string]any {
+	return map[string]any{
+		"edge_id":      e.EdgeID,
+		"from_node_id": e.FromNodeID,
+		"to_node_id":   e.ToNodeID,
+		"weight":       e.Weight,
+		"tag":          e.Tag,
+	}
+}
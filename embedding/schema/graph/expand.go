@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// MaxHops caps variable length matches, which grow exponentially
+	MaxHops = 3
+
+	defaultExpandLimit = 1000
+)
+
+// ExpandOptions restricts which edges a traversal may follow
+type ExpandOptions struct {
+	// Hops is the max path length, 1 to MaxHops
+	Hops int
+	// Tag keeps only edges with this tag when set
+	Tag string
+	// Types keeps only these relationship types when set; HAS_CHILD is
+	// never followed
+	Types     []string
+	MinWeight float64
+	// SeedScores weighs paths by the score of their seed, 1 for seeds
+	// missing from the map
+	SeedScores map[string]float64
+	// Decay multiplies the path score once per hop after the first; 0
+	// means no decay
+	Decay float64
+	// Limit caps the returned paths
+	Limit int
+}
+
+// Neighbor is a node reached from a seed, with the path that reached it
+type Neighbor struct {
+	Seed      string
+	NodeID    string
+	ChunkID   string
+	ArticleID string
+	Title     string
+	Tag       string
+	// Path lists node ids from the seed to the neighbor, Weights the edge
+	// weights along it
+	Path    []string
+	Weights []float64
+}
+
+const expandQuery = `UNWIND $seeds AS seed
+MATCH path = (s:Node {node_id: seed})-[rels*1..%d]-(n:Node)
+WHERE NOT n.node_id IN $seeds
+  AND all(r IN rels WHERE type(r) <> 'HAS_CHILD'
+      AND ($tag = '' OR r.tag = $tag)
+      AND (size($types) = 0 OR type(r) IN $types)
+      AND coalesce(r.weight, 0.0) >= $min_weight)
+WITH seed, n, path, [r IN rels | toFloat(coalesce(r.weight, 0.0))] AS weights
+WITH seed, n, path, weights,
+     coalesce($seed_scores[seed], 1.0)
+       * reduce(s = 1.0, w IN weights | s * w)
+       * $decay ^ (length(path) - 1) AS score
+RETURN seed, n,
+       [x IN nodes(path) | x.node_id] AS via,
+       weights
+ORDER BY score DESC, length(path), n.node_id
+LIMIT $limit`
+
+// Expand returns every path of up to opts.Hops weighted edges, in either
+// direction, from the seed nodes to nodes that are not seeds themselves.
+// Paths are ranked by seed score * product(weights) * decay^(hops-1), the
+// score recall gives them, before opts.Limit cuts them, so the strongest
+// neighbors survive the limit.
+func (r *Repository) Expand(ctx context.Context, seeds []string, opts ExpandOptions) ([]Neighbor, error) {
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+	if opts.Hops < 1 || opts.Hops > MaxHops {
+		return nil, fmt.Errorf("graph: hops must be in [1, %d], got %d", MaxHops, opts.Hops)
+	}
+	types := []string{}
+	for _, t := range opts.Types {
+		if !relTypePattern.MatchString(t) {
+			return nil, fmt.Errorf("graph: invalid edge type %q", t)
+		}
+		types = append(types, t)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultExpandLimit
+	}
+	decay := opts.Decay
+	if decay == 0 {
+		decay = 1
+	}
+	if decay < 0 || decay > 1 {
+		return nil, fmt.Errorf("graph: decay must be in (0, 1], got %g", opts.Decay)
+	}
+	seedScores := make(map[string]any, len(opts.SeedScores))
+	for seed, s := range opts.SeedScores {
+		seedScores[seed] = s
+	}
+
+	res, err := r.read(ctx, fmt.Sprintf(expandQuery, opts.Hops), map[string]any{
+		"seeds":       seeds,
+		"tag":         opts.Tag,
+		"types":       types,
+		"min_weight":  opts.MinWeight,
+		"seed_scores": seedScores,
+		"decay":       decay,
+		"limit":       limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Neighbor, 0, len(res.Records))
+	for _, rec := range res.Records {
+		seed, _, err := neo4j.GetRecordValue[string](rec, "seed")
+		if err != nil {
+			return nil, err
+		}
+		n, _, err := neo4j.GetRecordValue[neo4j.Node](rec, "n")
+		if err != nil {
+			return nil, err
+		}
+		via, _ := rec.Get("via")
+		weights, _ := rec.Get("weights")
+		out = append(out, Neighbor{
+			Seed:      seed,
+			NodeID:    stringProp(n.Props, "node_id"),
+			ChunkID:   stringProp(n.Props, "chunk_id"),
+			ArticleID: stringProp(n.Props, "article_id"),
+			Title:     stringProp(n.Props, "title"),
+			Tag:       stringProp(n.Props, "tag"),
+			Path:      stringsProp(map[string]any{"v": via}, "v"),
+			Weights:   floats(weights),
+		})
+	}
+	return out, nil
+}
+
+func floats(v any) []float64 {
+	list, _ := v.([]any)
+	out := make([]float64, 0, len(list))
+	for _, item := range list {
+		switch x := item.(type) {
+		case float64:
+			out = append(out, x)
+		case int64:
+			out = append(out, float64(x))
+		}
+	}
+	return out
+}
@@ -1 +1,93 @@
 package graph
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const upsertParentsQuery = `UNWIND $rows AS row
+MERGE (n:Node {node_id: row.node_id})
+SET n:Parent,
+    n.article_id = row.article_id,
+    n.chunk_id = row.chunk_id,
+    n.title = row.title,
+    n.tag = row.tag,
+    n.keywords = row.keywords`
+
+// UpsertParents creates or updates parent nodes in batches
+func (r *Repository) UpsertParents(ctx context.Context, parents []ParentNode) error {
+	rows := make([]map[string]any, len(parents))
+	for i, p := range parents {
+		rows[i] = parentRow(p)
+	}
+	return r.writeBatches(ctx, upsertParentsQuery, rows)
+}
+
+func (r *Repository) UpsertParent(ctx context.Context, parent ParentNode) error {
+	return r.UpsertParents(ctx, []ParentNode{parent})
+}
+
+// GetParent returns ErrNotFound when no parent has nodeID
+func (r *Repository) GetParent(ctx context.Context, nodeID string) (*ParentNode, error) {
+	return r.oneParent(ctx, `MATCH (n:Node:Parent {node_id: $v}) RETURN n`, nodeID)
+}
+
+// ParentByChunkID returns ErrNotFound when no parent has chunkID
+func (r *Repository) ParentByChunkID(ctx context.Context, chunkID string) (*ParentNode, error) {
+	return r.oneParent(ctx, `MATCH (n:Node:Parent {chunk_id: $v}) RETURN n LIMIT 1`, chunkID)
+}
+
+// ParentsByArticle returns the parents of an article ordered by chunk id
+func (r *Repository) ParentsByArticle(ctx context.Context, articleID string) ([]ParentNode, error) {
+	return r.parents(ctx, `MATCH (n:Node:Parent {article_id: $v}) RETURN n ORDER BY n.chunk_id`, articleID)
+}
+
+func (r *Repository) oneParent(ctx context.Context, query, value string) (*ParentNode, error) {
+	parents, err := r.parents(ctx, query, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) == 0 {
+		return nil, ErrNotFound
+	}
+	return &parents[0], nil
+}
+
+func (r *Repository) parents(ctx context.Context, query, value string) ([]ParentNode, error) {
+	res, err := r.read(ctx, query, map[string]any{"v": value})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := nodesFromResult(res)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ParentNode, len(nodes))
+	for i, n := range nodes {
+		out[i] = parentFromNode(n)
+	}
+	return out, nil
+}
+
+func parentRow(p ParentNode) map[string]any {
+	return map[string]any{
+		"node_id":    p.NodeID,
+		"article_id": p.ArticleID,
+		"chunk_id":   p.ChunkID,
+		"title":      p.Title,
+		"tag":        p.Tag,
+		"keywords":   keywordsParam(p.Keywords),
+	}
+}
+
+func parentFromNode(n neo4j.Node) ParentNode {
+	return ParentNode{
+		NodeID:    stringProp(n.Props, "node_id"),
+		ArticleID: stringProp(n.Props, "article_id"),
+		ChunkID:   stringProp(n.Props, "chunk_id"),
+		Title:     stringProp(n.Props, "title"),
+		Tag:       stringProp(n.Props, "tag"),
+		Keywords:  stringsProp(n.Props, "keywords"),
+	}
+}
@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Every node carries the Node label next to Parent or Child, so node_id is
+// unique across both kinds and edges can join any two nodes by id.
+const (
+	LabelNode   = "Node"
+	LabelParent = "Parent"
+	LabelChild  = "Child"
+
+	RelHasChild  = "HAS_CHILD"
+	RelRelatedTo = "RELATED_TO"
+
+	defaultBatchSize = 500
+)
+
+var ErrNotFound = errors.New("graph: node not found")
+
+// schemaStatements are idempotent and run by EnsureSchema on every start.
+// The indexes are on :Node, so lookups must match :Node as well as
+// :Parent or :Child for the planner to use them.
+var schemaStatements = []string{
+	"CREATE CONSTRAINT node_id_unique IF NOT EXISTS FOR (n:Node) REQUIRE n.node_id IS UNIQUE",
+	"CREATE INDEX node_article_id IF NOT EXISTS FOR (n:Node) ON (n.article_id)",
+	"CREATE INDEX node_chunk_id IF NOT EXISTS FOR (n:Node) ON (n.chunk_id)",
+}
+
+// Repository reads and writes the article graph. Writes use MERGE on
+// node_id/edge_id so replaying an ingest is safe.
+type Repository struct {
+	driver    neo4j.DriverWithContext
+	database  string
+	batchSize int
+}
+
+// NewRepository wraps a shared driver; database may be empty for the default
+func NewRepository(driver neo4j.DriverWithContext, database string) *Repository {
+	return &Repository{driver: driver, database: database, batchSize: defaultBatchSize}
+}
+
+// WithBatchSize sets how many rows one UNWIND write carries
+func (r *Repository) WithBatchSize(n int) *Repository {
+	if n > 0 {
+		r.batchSize = n
+	}
+	return r
+}
+
+// EnsureSchema creates the uniqueness constraint and lookup indexes
+func (r *Repository) EnsureSchema(ctx context.Context) error {
+	for _, stmt := range schemaStatements {
+		if _, err := r.write(ctx, stmt, nil); err != nil {
+			return fmt.Errorf("graph schema %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// DeleteArticle removes every node of the article together with its edges
+// and returns the number of deleted nodes
+func (r *Repository) DeleteArticle(ctx context.Context, articleID string) (int64, error) {
+	res, err := r.write(ctx,
+		`MATCH (n:Node {article_id: $article_id})
+DETACH DELETE n
+RETURN count(n) AS deleted`,
+		map[string]any{"article_id": articleID})
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Records) == 0 {
+		return 0, nil
+	}
+	deleted, _, err := neo4j.GetRecordValue[int64](res.Records[0], "deleted")
+	return deleted, err
+}
+
+// PruneArticle deletes the nodes of the article whose node_id is not in
+// keep, so a re-ingested article does not leave old chunks behind
+func (r *Repository) PruneArticle(ctx context.Context, articleID string, keep []string) (int64, error) {
+	if keep == nil {
+		keep = []string{}
+	}
+	res, err := r.write(ctx,
+		`MATCH (n:Node {article_id: $article_id})
+WHERE NOT n.node_id IN $keep
+DETACH DELETE n
+RETURN count(n) AS deleted`,
+		map[string]any{"article_id": articleID, "keep": keep})
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Records) == 0 {
+		return 0, nil
+	}
+	deleted, _, err := neo4j.GetRecordValue[int64](res.Records[0], "deleted")
+	return deleted, err
+}
+
+func (r *Repository) write(ctx context.Context, query string, params map[string]any) (*neo4j.EagerResult, error) {
+	return r.execute(ctx, query, params, neo4j.ExecuteQueryWithWritersRouting())
+}
+
+func (r *Repository) read(ctx context.Context, query string, params map[string]any) (*neo4j.EagerResult, error) {
+	return r.execute(ctx, query, params, neo4j.ExecuteQueryWithReadersRouting())
+}
+
+func (r *Repository) execute(ctx context.Context, query string, params map[string]any, opts ...neo4j.ExecuteQueryConfigurationOption) (*neo4j.EagerResult, error) {
+	if r.database != "" {
+		opts = append(opts, neo4j.ExecuteQueryWithDatabase(r.database))
+	}
+	return neo4j.ExecuteQuery(ctx, r.driver, query, params, neo4j.EagerResultTransformer, opts...)
+}
+
+// writeBatches runs query once per batch of rows, bound to $rows
+func (r *Repository) writeBatches(ctx context.Context, query string, rows []map[string]any) error {
+	for start := 0; start < len(rows); start += r.batchSize {
+		end := min(start+r.batchSize, len(rows))
+		if _, err := r.write(ctx, query, map[string]any{"rows": rows[start:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodesFromResult decodes the "n" column of every record
+func nodesFromResult(res *neo4j.EagerResult) ([]neo4j.Node, error) {
+	nodes := make([]neo4j.Node, 0, len(res.Records))
+	for _, rec := range res.Records {
+		n, _, err := neo4j.GetRecordValue[neo4j.Node](rec, "n")
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func stringProp(props map[string]any, key string) string {
+	s, _ := props[key].(string)
+	return s
+}
+
+func stringsProp(props map[string]any, key string) []string {
+	switch v := props[key].(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func keywordsParam(keywords []string) []string {
+	// Neo4j 不接受 null 列表元素，nil 统一存成空列表
+	if keywords == nil {
+		return []string{}
+	}
+	return keywords
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 父节点写入参数和读回的节点能互相还原
+func TestParentRoundTrip(t *testing.T) {
+	p := ParentNode{NodeID: "p1", ArticleID: "a1", ChunkID: "a1#0", Title: "标题", Tag: "go", Keywords: []string{"milvus", "neo4j"}}
+	row := parentRow(p)
+	// 模拟驱动返回的列表类型
+	row["keywords"] = []any{"milvus", "neo4j"}
+	got := parentFromNode(neo4j.Node{Props: row})
+	assert.Equal(t, p, got)
+}
+
+// 子节点保留文章和父节点关联
+func TestChildRoundTrip(t *testing.T) {
+	c := ChildNode{NodeID: "c1", ArticleID: "a1", ParentNodeID: "p1", ChunkID: "a1#0#1", Title: "t", Tag: "go"}
+	row := childRow(c)
+	assert.Equal(t, []string{}, row["keywords"])
+	got := childFromNode(neo4j.Node{Props: row})
+	assert.Equal(t, "p1", got.ParentNodeID)
+	assert.Equal(t, "a1", got.ArticleID)
+	assert.Empty(t, got.Keywords)
+}
+
+// 边类型为空时使用默认类型，非法类型拒绝拼进查询
+func TestEdgeType(t *testing.T) {
+	got, err := edgeType("")
+	require.NoError(t, err)
+	assert.Equal(t, RelRelatedTo, got)
+
+	got, err = edgeType("SIMILAR_TO")
+	require.NoError(t, err)
+	assert.Equal(t, "SIMILAR_TO", got)
+
+	for _, bad := range []string{"similar", "A]->(b) DETACH DELETE b //", "1ABC", "HAS-CHILD"} {
+		_, err := edgeType(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// 批量大小只接受正数
+func TestWithBatchSize(t *testing.T) {
+	r := NewRepository(nil, "")
+	assert.Equal(t, defaultBatchSize, r.batchSize)
+	assert.Equal(t, 10, r.WithBatchSize(10).batchSize)
+	assert.Equal(t, 10, r.WithBatchSize(0).batchSize)
+}
+
+// 路径上的边权统一转成 float64
+func TestFloats(t *testing.T) {
+	assert.Equal(t, []float64{0.5, 2}, floats([]any{0.5, int64(2), "x"}))
+	assert.Empty(t, floats(nil))
+}
@@ -10,17 +10,22 @@ type ParentNode struct {
 }
 
 type ChildNode struct {
-	NodeID   string
-	ChunkID  string
-	Title    string
-	Tag      string
-	Keywords []string
+	NodeID    string
+	ArticleID string
+	// ParentNodeID links the chunk to its parent with a HAS_CHILD edge
+	ParentNodeID string
+	ChunkID      string
+	Title        string
+	Tag          string
+	Keywords     []string
 }
 
 type Edge struct {
 	EdgeID     string
 	FromNodeID string
 	ToNodeID   string
-	Weight     float64
-	Tag        string
+	// Type is the relationship type, RELATED_TO when empty
+	Type   string
+	Weight float64
+	Tag    string
 }
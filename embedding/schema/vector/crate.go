@@ -1 +1,264 @@
 package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sea/config"
+	"strings"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/index"
+)
+
+// Collection names of the two recall stages
+const (
+	RecallCandidateCollection = "RecallCandidateCollection"
+	RecallPreciseCollection   = "RecallPreciseCollection"
+)
+
+// Field names shared by the recall collections. Sparse is filled by the
+// BM25 function from Text and is never written by clients.
+const (
+	FieldID     = "id"
+	FieldVector = "vector"
+	FieldTag    = "tag"
+	FieldText   = "text"
+	FieldSparse = "sparse"
+)
+
+// Dynamic fields written next to every chunk vector. They are not declared
+// in the schema and are read back as output fields.
+const (
+	FieldArticleID = "article_id"
+	FieldParentID  = "parent_id"
+	FieldNodeID    = "node_id"
+	FieldCreatedAt = "created_at"
+)
+
+// VectorType is the element type of the vector field of a recall
+// collection. The candidate collection stores float16 so its wide first
+// stage index takes half the memory; the precise collection keeps float32
+// for the final ranking.
+func VectorType(collection string) entity.FieldType {
+	if collection == RecallCandidateCollection {
+		return entity.FieldTypeFloat16Vector
+	}
+	return entity.FieldTypeFloatVector
+}
+
+// Index and function names created by the bootstrapper
+const (
+	VectorIndexName  = "vector_idx"
+	TagIndexName     = "tag_idx"
+	SparseIndexName  = "sparse_idx"
+	BM25FunctionName = "text_bm25"
+)
+
+// TextMaxLength is the max byte length of the text field
+const TextMaxLength = 8192
+
+// IDMaxLength is the max byte length of the primary key
+const IDMaxLength = 128
+
+const (
+	tagMaxLength = 256
+
+	// sparseDropRatio drops the smallest weights when building the index
+	sparseDropRatio = 0.2
+)
+
+// CollectionSpec is everything needed to create and index one collection
+type CollectionSpec struct {
+	Schema      *entity.Schema
+	VectorIndex index.Index
+	SparseIndex index.Index
+}
+
+// RecallCollections returns the specs of both recall collections for dim
+// sized vectors, with vector indexes built from cfg
+func RecallCollections(dim int, cfg config.MilvusIndexConfig) ([]CollectionSpec, error) {
+	metric := metricType(cfg.Metric)
+	candidate, err := vectorIndex(cfg.Candidate, metric, "IVF_FLAT")
+	if err != nil {
+		return nil, fmt.Errorf("candidate index: %w", err)
+	}
+	precise, err := vectorIndex(cfg.Precise, metric, "HNSW")
+	if err != nil {
+		return nil, fmt.Errorf("precise index: %w", err)
+	}
+	sparse := index.NewSparseInvertedIndex(entity.BM25, sparseDropRatio)
+	return []CollectionSpec{
+		{Schema: RecllCandidateTableName(dim, cfg.TextAnalyzer), VectorIndex: candidate, SparseIndex: sparse},
+		{Schema: RecallPreciseTableName(dim, cfg.TextAnalyzer), VectorIndex: precise, SparseIndex: sparse},
+	}, nil
+}
+
+// textField is the chunk text, tokenized by analyzer ("standard",
+// "chinese", "english"; empty keeps the milvus default) for BM25
+func textField(analyzer string) *entity.Field {
+	f := entity.NewField().
+		WithName(FieldText).
+		WithDataType(entity.FieldTypeVarChar).
+		WithMaxLength(TextMaxLength).
+		WithEnableAnalyzer(true)
+	if analyzer = strings.TrimSpace(analyzer); analyzer != "" {
+		f = f.WithAnalyzerParams(map[string]any{"type": analyzer})
+	}
+	return f
+}
+
+func sparseField() *entity.Field {
+	return entity.NewField().
+		WithName(FieldSparse).
+		WithDataType(entity.FieldTypeSparseVector)
+}
+
+// bm25Function derives the sparse vector from text on insert and the query
+// sparse vector from raw text on search
+func bm25Function() *entity.Function {
+	return entity.NewFunction().
+		WithName(BM25FunctionName).
+		WithType(entity.FunctionTypeBM25).
+		WithInputFields(FieldText).
+		WithOutputFields(FieldSparse)
+}
+
+// MetricType returns the configured distance metric, COSINE by default
+func MetricType(cfg config.MilvusIndexConfig) entity.MetricType {
+	return metricType(cfg.Metric)
+}
+
+func metricType(name string) entity.MetricType {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "L2":
+		return entity.L2
+	case "IP":
+		return entity.IP
+	default:
+		return entity.COSINE
+	}
+}
+
+// vectorIndex maps an index config onto a milvus index, defaulting the type
+// and its build parameters when unset
+func vectorIndex(cfg config.VectorIndexConfig, metric entity.MetricType, defaultType string) (index.Index, error) {
+	typ := strings.ToUpper(strings.TrimSpace(cfg.Type))
+	if typ == "" {
+		typ = defaultType
+	}
+	nlist := cfg.NList
+	if nlist <= 0 {
+		nlist = 1024
+	}
+	switch typ {
+	case "HNSW":
+		m, ef := cfg.M, cfg.EfConstruction
+		if m <= 0 {
+			m = 16
+		}
+		if ef <= 0 {
+			ef = 200
+		}
+		return index.NewHNSWIndex(metric, m, ef), nil
+	case "IVF_FLAT":
+		return index.NewIvfFlatIndex(metric, nlist), nil
+	case "IVF_SQ8":
+		return index.NewIvfSQ8Index(metric, nlist), nil
+	case "DISKANN":
+		return index.NewDiskANNIndex(metric), nil
+	case "AUTOINDEX":
+		return index.NewAutoIndex(metric), nil
+	default:
+		return nil, fmt.Errorf("unsupported vector index type: %s. Supported types: HNSW, IVF_FLAT, IVF_SQ8, DISKANN, AUTOINDEX", cfg.Type)
+	}
+}
+
+// SchemaDrift is one difference between the schema in code and a live collection
+type SchemaDrift struct {
+	Collection string
+	Field      string
+	Detail     string
+}
+
+func (d SchemaDrift) String() string {
+	return fmt.Sprintf("%s.%s: %s", d.Collection, d.Field, d.Detail)
+}
+
+// DiffSchema lists the differences of live against want: missing or extra
+// fields, data type, primary key, vector dim, varchar length, analyzer and
+// its params, missing functions and whether dynamic fields are enabled
+func DiffSchema(want, live *entity.Schema) []SchemaDrift {
+	var drift []SchemaDrift
+	add := func(field, format string, args ...any) {
+		drift = append(drift, SchemaDrift{Collection: want.CollectionName, Field: field, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if want.EnableDynamicField != live.EnableDynamicField {
+		add("$meta", "dynamic field enabled %v in code, %v live", want.EnableDynamicField, live.EnableDynamicField)
+	}
+
+	liveFields := make(map[string]*entity.Field, len(live.Fields))
+	for _, f := range live.Fields {
+		// The hidden $meta field backs dynamic fields and is not declared in code
+		if f.IsDynamic {
+			continue
+		}
+		liveFields[f.Name] = f
+	}
+	for _, w := range want.Fields {
+		l, ok := liveFields[w.Name]
+		if !ok {
+			add(w.Name, "missing in live collection")
+			continue
+		}
+		delete(liveFields, w.Name)
+		if w.DataType != l.DataType {
+			add(w.Name, "type %s in code, %s live", w.DataType.Name(), l.DataType.Name())
+			continue
+		}
+		if w.PrimaryKey != l.PrimaryKey {
+			add(w.Name, "primary key %v in code, %v live", w.PrimaryKey, l.PrimaryKey)
+		}
+		for _, param := range []string{entity.TypeParamDim, entity.TypeParamMaxLength, "enable_analyzer"} {
+			if wv, ok := w.TypeParams[param]; ok && wv != l.TypeParams[param] {
+				add(w.Name, "%s %s in code, %s live", param, wv, l.TypeParams[param])
+			}
+		}
+		if wv, lv := w.TypeParams[analyzerParams], l.TypeParams[analyzerParams]; !sameJSON(wv, lv) {
+			add(w.Name, "%s %s in code, %s live", analyzerParams, wv, lv)
+		}
+	}
+	for name := range liveFields {
+		add(name, "only in live collection")
+	}
+
+	liveFuncs := make(map[string]bool, len(live.Functions))
+	for _, f := range live.Functions {
+		liveFuncs[f.Name] = true
+	}
+	for _, f := range want.Functions {
+		if !liveFuncs[f.Name] {
+			add("function:"+f.Name, "missing in live collection")
+		}
+	}
+	return drift
+}
+
+const analyzerParams = "analyzer_params"
+
+// sameJSON compares two JSON type params by value, so key order and
+// whitespace from the server do not count as drift
+func sameJSON(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if a == "" || b == "" {
+		return false
+	}
+	var av, bv any
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"sea/config"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecallCollectionsIndexes 测试按配置生成两个集合的向量索引
+func TestRecallCollectionsIndexes(t *testing.T) {
+	specs, err := RecallCollections(1024, config.MilvusIndexConfig{
+		Candidate: config.VectorIndexConfig{Type: "diskann"},
+	})
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+
+	assert.Equal(t, RecallCandidateCollection, specs[0].Schema.CollectionName)
+	assert.Equal(t, index.DISKANN, specs[0].VectorIndex.IndexType())
+	assert.Equal(t, RecallPreciseCollection, specs[1].Schema.CollectionName)
+	assert.Equal(t, index.HNSW, specs[1].VectorIndex.IndexType())
+	assert.Equal(t, "COSINE", specs[1].VectorIndex.Params()["metric_type"])
+
+	// 粗召回存 float16，精排存 float32，维度一致
+	assert.Equal(t, entity.FieldTypeFloat16Vector, specs[0].Schema.Fields[1].DataType)
+	assert.Equal(t, entity.FieldTypeFloatVector, specs[1].Schema.Fields[1].DataType)
+	for _, spec := range specs {
+		dim, err := spec.Schema.Fields[1].GetDim()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1024), dim)
+	}
+
+	_, err = RecallCollections(1024, config.MilvusIndexConfig{
+		Precise: config.VectorIndexConfig{Type: "LSH"},
+	})
+	assert.Error(t, err)
+}
+
+// TestDiffSchema 测试线上schema与代码的差异检测
+func TestDiffSchema(t *testing.T) {
+	want := RecallPreciseTableName(2048, "chinese")
+
+	// 完全一致（线上额外的 $meta 动态字段不算差异）
+	live := RecallPreciseTableName(2048, "chinese")
+	live.WithField(entity.NewField().WithName("$meta").WithDataType(entity.FieldTypeJSON).WithIsDynamic(true))
+	assert.Empty(t, DiffSchema(want, live))
+
+	// 分词器参数只是格式不同不算差异，换了分词器算
+	live = RecallPreciseTableName(2048, "")
+	live.Fields[3].TypeParams["analyzer_params"] = `{ "type" : "chinese" }`
+	assert.Empty(t, DiffSchema(want, live))
+	live = RecallPreciseTableName(2048, "standard")
+	drift := DiffSchema(want, live)
+	require.Len(t, drift, 1)
+	assert.Equal(t, FieldText, drift[0].Field)
+	assert.Len(t, DiffSchema(want, RecallPreciseTableName(2048, "")), 1)
+
+	// 维度不同、缺少tag、多出字段
+	live = entity.NewSchema().
+		WithName(RecallPreciseCollection).
+		WithDynamicFieldEnabled(true).
+		WithField(entity.NewField().WithName(FieldID).WithDataType(entity.FieldTypeVarChar).WithMaxLength(IDMaxLength).WithIsPrimaryKey(true)).
+		WithField(entity.NewField().WithName(FieldVector).WithDataType(entity.FieldTypeFloatVector).WithDim(1024)).
+		WithField(entity.NewField().WithName("legacy").WithDataType(entity.FieldTypeInt64))
+
+	drift = DiffSchema(want, live)
+	var fields []string
+	for _, d := range drift {
+		assert.Equal(t, RecallPreciseCollection, d.Collection)
+		fields = append(fields, d.Field)
+	}
+	assert.ElementsMatch(t, []string{FieldVector, FieldTag, FieldText, FieldSparse, "function:" + BM25FunctionName, "legacy"}, fields)
+}
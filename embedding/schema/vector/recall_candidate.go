@@ -1,30 +1,40 @@
 package schema
 
-import "github.com/milvus-io/milvus/client/v2/entity"
+import (
+	"strconv"
 
-func RecllCandidateTableName() *entity.Schema {
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// RecllCandidateTableName builds the coarse recall schema for dim sized
+// float16 vectors with BM25 over the text tokenized by analyzer
+func RecllCandidateTableName(dim int, analyzer string) *entity.Schema {
 	chunkId := entity.NewField().
-		WithName("id").
-		WithDataType(entity.FieldTypeString).
+		WithName(FieldID).
+		WithDataType(entity.FieldTypeVarChar).
+		WithMaxLength(IDMaxLength).
 		WithIsPrimaryKey(true).
 		WithIsAutoID(false)
 
 	vec := entity.NewField().
-		WithName("vector").
-		WithDataType(entity.FieldTypeFloatVector).
-		WithDim(2048)
+		WithName(FieldVector).
+		WithDataType(VectorType(RecallCandidateCollection)).
+		WithDim(int64(dim))
 
 	tag := entity.NewField().
-		WithName("tag").
+		WithName(FieldTag).
 		WithDataType(entity.FieldTypeVarChar).
-		WithTypeParams(entity.TypeParamMaxLength, "256")
+		WithTypeParams(entity.TypeParamMaxLength, strconv.Itoa(tagMaxLength))
 
 	return entity.NewSchema().
-		WithName("RecallCandidateCollection").
-		WithDescription("coarse recall vectors").
+		WithName(RecallCandidateCollection).
+		WithDescription("float16 vectors for coarse recall").
 		WithAutoID(false).
 		WithDynamicFieldEnabled(true).
 		WithField(chunkId).
 		WithField(vec).
-		WithField(tag)
+		WithField(tag).
+		WithField(textField(analyzer)).
+		WithField(sparseField()).
+		WithFunction(bm25Function())
 }
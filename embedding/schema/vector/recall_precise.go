@@ -1,30 +1,40 @@
 package schema
 
-import "github.com/milvus-io/milvus/client/v2/entity"
+import (
+	"strconv"
 
-func RecallPreciseTableName() *entity.Schema {
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// RecallPreciseTableName builds the precise recall schema for dim sized vectors
+// with BM25 over the text tokenized by analyzer
+func RecallPreciseTableName(dim int, analyzer string) *entity.Schema {
 	id := entity.NewField().
-		WithName("id").
-		WithDataType(entity.FieldTypeString).
+		WithName(FieldID).
+		WithDataType(entity.FieldTypeVarChar).
+		WithMaxLength(IDMaxLength).
 		WithIsPrimaryKey(true).
 		WithIsAutoID(false)
 
 	vec := entity.NewField().
-		WithName("vector").
-		WithDataType(entity.FieldTypeFloatVector).
-		WithDim(2048)
+		WithName(FieldVector).
+		WithDataType(VectorType(RecallPreciseCollection)).
+		WithDim(int64(dim))
 
 	tag := entity.NewField().
-		WithName("tag").
+		WithName(FieldTag).
 		WithDataType(entity.FieldTypeVarChar).
-		WithTypeParams(entity.TypeParamMaxLength, "256")
+		WithTypeParams(entity.TypeParamMaxLength, strconv.Itoa(tagMaxLength))
 
 	return entity.NewSchema().
-		WithName("RecallPreciseCollection").
-		WithDescription("coarse recall vectors").
+		WithName(RecallPreciseCollection).
+		WithDescription("full precision vectors for reranking recall candidates").
 		WithAutoID(false).
 		WithDynamicFieldEnabled(true).
 		WithField(id).
 		WithField(vec).
-		WithField(tag)
+		WithField(tag).
+		WithField(textField(analyzer)).
+		WithField(sparseField()).
+		WithFunction(bm25Function())
 }
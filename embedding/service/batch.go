@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sea/config"
+	"strings"
+	"sync"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Provider batch limits used when the config leaves batch_size unset
+const (
+	defaultDashScopeBatchSize = 10
+	defaultOpenAIBatchSize    = 2048
+	defaultBatchConcurrency   = 4
+)
+
+// ErrEmptyInput is reported for blank texts, which providers reject
+var ErrEmptyInput = errors.New("embedding: empty input")
+
+// TextResult is the outcome for one input of a batch call.
+// Exactly one of Embedding and Err is set.
+type TextResult struct {
+	Index     int
+	Embedding []float64
+	Err       error
+}
+
+// BatchOptions controls how a batch call is split and scheduled
+type BatchOptions struct {
+	// BatchSize caps texts per provider request; 0 uses the provider limit
+	BatchSize int
+	// Concurrency caps provider requests in flight; 0 uses the default
+	Concurrency int
+}
+
+// batchSizer is implemented by embedders with a provider side batch limit
+type batchSizer interface {
+	MaxBatchSize() int
+}
+
+// MaxBatchSize reports the max texts per compatible-mode request
+func (e *DashScopeEmbedder) MaxBatchSize() int {
+	if e.cfg.BatchSize > 0 {
+		return e.cfg.BatchSize
+	}
+	return defaultDashScopeBatchSize
+}
+
+// MaxBatchSize reports the max texts per embeddings request
+func (e *OpenAIEmbedder) MaxBatchSize() int {
+	if e.cfg.BatchSize > 0 {
+		return e.cfg.BatchSize
+	}
+	return defaultOpenAIBatchSize
+}
+
+// EmbeddingTxtBatch embeds texts with the configured embedder.
+// See EmbedTextsBatched for the result contract.
+func EmbeddingTxtBatch(ctx context.Context, texts []string) ([]TextResult, error) {
+	return EmbedTextsBatched(ctx, Default(), texts, BatchOptions{
+		Concurrency: config.Cfg.Embedding.BatchConcurrency,
+	})
+}
+
+// EmbedTextsBatched splits texts into provider sized batches, runs them with
+// bounded concurrency and returns one TextResult per input in input order.
+// A batch rejected for its content (InvalidInputError, ModerationError) is
+// retried item by item so one bad text does not fail its neighbours; any
+// other failure, such as quota, auth or rate limits, applies to every item
+// of the batch as is. The returned error is only set when ctx ends before all
+// batches were scheduled; per-item failures are reported in TextResult.Err.
+func EmbedTextsBatched(ctx context.Context, e Embedder, texts []string, opts BatchOptions) ([]TextResult, error) {
+	results := make([]TextResult, len(texts))
+	pending := make([]int, 0, len(texts))
+	for i, t := range texts {
+		results[i].Index = i
+		if strings.TrimSpace(t) == "" {
+			results[i].Err = ErrEmptyInput
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	size := opts.BatchSize
+	if size <= 0 {
+		if s, ok := e.(batchSizer); ok {
+			size = s.MaxBatchSize()
+		}
+	}
+	if size <= 0 {
+		size = len(pending)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var scheduleErr error
+	for start := 0; start < len(pending); start += size {
+		end := min(start+size, len(pending))
+		batch := pending[start:end]
+
+		if scheduleErr = acquire(ctx, sem); scheduleErr != nil {
+			for _, idx := range pending[start:] {
+				results[idx].Err = scheduleErr
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			embedBatch(ctx, e, texts, batch, results)
+		}()
+	}
+	wg.Wait()
+	return results, scheduleErr
+}
+
+// acquire takes a slot from sem unless ctx is already done
+func acquire(ctx context.Context, sem chan struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// embedBatch fills results for the inputs at idx. Each goroutine owns a
+// disjoint set of indexes, so results needs no locking.
+func embedBatch(ctx context.Context, e Embedder, texts []string, idx []int, results []TextResult) {
+	inputs := make([]string, len(idx))
+	for i, j := range idx {
+		inputs[i] = texts[j]
+	}
+
+	res, err := e.EmbedTexts(ctx, inputs)
+	if err == nil {
+		err = scatter(res, idx, results)
+	}
+	if err == nil {
+		return
+	}
+	if len(idx) == 1 || ctx.Err() != nil || !isInputError(err) {
+		for _, j := range idx {
+			results[j].Embedding = nil
+			results[j].Err = err
+		}
+		return
+	}
+
+	// Isolate the failing inputs
+	for _, j := range idx {
+		embedBatch(ctx, e, texts, []int{j}, results)
+	}
+}
+
+// isInputError reports errors caused by some input of the batch, which
+// splitting the batch can narrow down
+func isInputError(err error) bool {
+	var inv *InvalidInputError
+	var mod *ModerationError
+	return errors.As(err, &inv) || errors.As(err, &mod)
+}
+
+// scatter maps response items back to input positions by their Index. The
+// response is checked as a whole before any result is written, so a bad
+// response leaves results untouched.
+func scatter(res *openai.CreateEmbeddingResponse, idx []int, results []TextResult) error {
+	if res == nil || len(res.Data) != len(idx) {
+		got := 0
+		if res != nil {
+			got = len(res.Data)
+		}
+		return fmt.Errorf("embedding batch returned %d vectors for %d inputs", got, len(idx))
+	}
+	seen := make([]bool, len(idx))
+	for _, d := range res.Data {
+		if d.Index < 0 || int(d.Index) >= len(idx) {
+			return fmt.Errorf("embedding batch returned out of range index %d", d.Index)
+		}
+		if seen[d.Index] {
+			return fmt.Errorf("embedding batch returned duplicate index %d", d.Index)
+		}
+		seen[d.Index] = true
+	}
+	for _, d := range res.Data {
+		j := idx[d.Index]
+		results[j].Embedding = d.Embedding
+		results[j].Err = nil
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubBatchEmbedder 记录调用情况的批量测试桩
+type stubBatchEmbedder struct {
+	*FakeEmbedder
+	mu       sync.Mutex
+	sizes    []int
+	inFlight int32
+	maxSeen  int32
+}
+
+func (s *stubBatchEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		old := atomic.LoadInt32(&s.maxSeen)
+		if n <= old || atomic.CompareAndSwapInt32(&s.maxSeen, old, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	s.mu.Lock()
+	s.sizes = append(s.sizes, len(texts))
+	s.mu.Unlock()
+
+	for _, t := range texts {
+		if strings.Contains(t, "bad") {
+			return nil, &InvalidInputError{&APIError{Provider: "stub", StatusCode: 400, Message: "invalid input"}}
+		}
+		if strings.Contains(t, "quota") {
+			return nil, &QuotaError{&APIError{Provider: "stub", StatusCode: 429, Code: "insufficient_quota"}}
+		}
+	}
+	return s.FakeEmbedder.EmbedTexts(ctx, texts)
+}
+
+func (s *stubBatchEmbedder) MaxBatchSize() int {
+	return 3
+}
+
+// TestEmbedTextsBatchedOrderAndChunking 测试分批、并发上限与结果顺序
+func TestEmbedTextsBatchedOrderAndChunking(t *testing.T) {
+	stub := &stubBatchEmbedder{FakeEmbedder: NewFakeEmbedder(8)}
+	texts := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	results, err := EmbedTextsBatched(context.Background(), stub, texts, BatchOptions{Concurrency: 2})
+	require.NoError(t, err)
+	require.Len(t, results, len(texts))
+
+	for i, r := range results {
+		assert.Equal(t, i, r.Index)
+		require.NoError(t, r.Err)
+		want, err := stub.FakeEmbedder.EmbedText(context.Background(), texts[i])
+		require.NoError(t, err)
+		assert.Equal(t, want.Data[0].Embedding, r.Embedding)
+	}
+	assert.ElementsMatch(t, []int{3, 3, 2}, stub.sizes)
+	assert.LessOrEqual(t, stub.maxSeen, int32(2))
+}
+
+// TestEmbedTextsBatchedPerItemErrors 测试单条失败不影响同批其他条目
+func TestEmbedTextsBatchedPerItemErrors(t *testing.T) {
+	stub := &stubBatchEmbedder{FakeEmbedder: NewFakeEmbedder(8)}
+	texts := []string{"ok1", "bad", "ok2", "  ", "ok3"}
+
+	results, err := EmbedTextsBatched(context.Background(), stub, texts, BatchOptions{})
+	require.NoError(t, err)
+
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.Nil(t, results[1].Embedding)
+	assert.NoError(t, results[2].Err)
+	assert.True(t, errors.Is(results[3].Err, ErrEmptyInput))
+	assert.NoError(t, results[4].Err)
+	assert.Len(t, results[4].Embedding, 8)
+}
+
+// TestEmbedTextsBatchedBatchErrors 测试非输入类错误整批返回，不逐条重试
+func TestEmbedTextsBatchedBatchErrors(t *testing.T) {
+	stub := &stubBatchEmbedder{FakeEmbedder: NewFakeEmbedder(8)}
+	texts := []string{"ok1", "quota", "ok2"}
+
+	results, err := EmbedTextsBatched(context.Background(), stub, texts, BatchOptions{})
+	require.NoError(t, err)
+
+	var q *QuotaError
+	for _, r := range results {
+		assert.ErrorAs(t, r.Err, &q)
+		assert.Nil(t, r.Embedding)
+	}
+	assert.Equal(t, []int{3}, stub.sizes)
+}
+
+// TestScatterRejectsBadIndexes 测试重复或越界的 Index 不会写入结果
+func TestScatterRejectsBadIndexes(t *testing.T) {
+	resp := func(indexes ...int64) *openai.CreateEmbeddingResponse {
+		res := &openai.CreateEmbeddingResponse{}
+		for _, i := range indexes {
+			res.Data = append(res.Data, openai.Embedding{Index: i, Embedding: []float64{float64(i)}})
+		}
+		return res
+	}
+	idx := []int{0, 1}
+
+	results := make([]TextResult, 2)
+	assert.ErrorContains(t, scatter(resp(0, 0), idx, results), "duplicate")
+	assert.ErrorContains(t, scatter(resp(0, 2), idx, results), "out of range")
+	assert.ErrorContains(t, scatter(resp(0), idx, results), "1 vectors for 2 inputs")
+	assert.Nil(t, results[0].Embedding)
+
+	require.NoError(t, scatter(resp(1, 0), idx, results))
+	assert.Equal(t, []float64{0}, results[0].Embedding)
+	assert.Equal(t, []float64{1}, results[1].Embedding)
+}
+
+// TestEmbedTextsBatchedCanceled 测试上下文取消后未调度的条目带上错误
+func TestEmbedTextsBatchedCanceled(t *testing.T) {
+	stub := &stubBatchEmbedder{FakeEmbedder: NewFakeEmbedder(8)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := EmbedTextsBatched(ctx, stub, []string{"a", "b", "c", "d"}, BatchOptions{BatchSize: 1, Concurrency: 1})
+	require.Error(t, err)
+	for _, r := range results {
+		assert.Error(t, r.Err)
+	}
+}
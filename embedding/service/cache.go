@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sea/zlog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"go.uber.org/zap"
+)
+
+// Cache stores embedding vectors by key.
+// A miss is reported as ok == false with a nil error.
+type Cache interface {
+	Get(ctx context.Context, key string) (vec []float64, ok bool, err error)
+	Set(ctx context.Context, key string, vec []float64, ttl time.Duration) error
+}
+
+// CacheStats is a snapshot of cache effectiveness
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// modelReporter is implemented by embedders that can name the model per modality
+type modelReporter interface {
+	TextModel() string
+	MultimodalModel() string
+}
+
+// TextModel reports the text embedding model
+func (e *DashScopeEmbedder) TextModel() string { return e.cfg.TextModel }
+
+// MultimodalModel reports the multimodal embedding model
+func (e *DashScopeEmbedder) MultimodalModel() string { return e.cfg.MultimodalModel }
+
+// TextModel reports the text embedding model
+func (e *OpenAIEmbedder) TextModel() string { return e.cfg.Model }
+
+// MultimodalModel is empty, the OpenAI API has no image embeddings
+func (e *OpenAIEmbedder) MultimodalModel() string { return "" }
+
+// TextModel reports the fake model name
+func (e *FakeEmbedder) TextModel() string { return fakeModel }
+
+// MultimodalModel reports the fake model name
+func (e *FakeEmbedder) MultimodalModel() string { return fakeModel }
+
+// CachedEmbedder serves repeated inputs from a Cache and only sends misses to
+// the wrapped Embedder. Keys cover model, dimensions, modality and the
+// normalized input, so changing any of them never returns a stale vector.
+// Cache failures are logged and treated as misses.
+type CachedEmbedder struct {
+	inner  Embedder
+	cache  Cache
+	ttl    time.Duration
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedEmbedder wraps inner with cache; ttl 0 keeps entries until evicted
+func NewCachedEmbedder(inner Embedder, cache Cache, ttl time.Duration) *CachedEmbedder {
+	return &CachedEmbedder{inner: inner, cache: cache, ttl: ttl}
+}
+
+// Stats returns hit/miss counters since creation
+func (c *CachedEmbedder) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Dimensions reports the wrapped embedder's dimension
+func (c *CachedEmbedder) Dimensions() int {
+	return c.inner.Dimensions()
+}
+
+// MaxBatchSize forwards the wrapped embedder's batch limit
+func (c *CachedEmbedder) MaxBatchSize() int {
+	if s, ok := c.inner.(batchSizer); ok {
+		return s.MaxBatchSize()
+	}
+	return 0
+}
+
+// TextModel reports the wrapped embedder's text model
+func (c *CachedEmbedder) TextModel() string {
+	return c.textModel()
+}
+
+// MultimodalModel reports the wrapped embedder's multimodal model
+func (c *CachedEmbedder) MultimodalModel() string {
+	return c.multimodalModel()
+}
+
+// EmbedText embeds a single text through the cache
+func (c *CachedEmbedder) EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error) {
+	return c.EmbedTexts(ctx, []string{text})
+}
+
+// EmbedTexts looks every text up and embeds only the misses in one call
+func (c *CachedEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	keys := make([]string, len(texts))
+	for i, t := range texts {
+		keys[i] = c.key("text", normalizeText(t))
+	}
+	return c.many(ctx, keys, c.textModel(), func(missIdx []int) (*openai.CreateEmbeddingResponse, error) {
+		miss := make([]string, len(missIdx))
+		for i, j := range missIdx {
+			miss[i] = texts[j]
+		}
+		return c.inner.EmbedTexts(ctx, miss)
+	})
+}
+
+// EmbedImage embeds a single image URL through the cache
+func (c *CachedEmbedder) EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	key := c.key("image", strings.TrimSpace(imageURL))
+	return c.single(ctx, key, func() (*openai.CreateEmbeddingResponse, error) {
+		return c.inner.EmbedImage(ctx, imageURL)
+	})
+}
+
+// EmbedMultiImages embeds several image URLs through the cache.
+// Order matters to the model, so it is part of the key.
+func (c *CachedEmbedder) EmbedMultiImages(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	trimmed := make([]string, len(imageURLs))
+	for i, u := range imageURLs {
+		trimmed[i] = strings.TrimSpace(u)
+	}
+	key := c.key("multi_images", strings.Join(trimmed, "\n"))
+	return c.single(ctx, key, func() (*openai.CreateEmbeddingResponse, error) {
+		return c.inner.EmbedMultiImages(ctx, imageURLs)
+	})
+}
+
+// EmbedMultimodal looks every item up and sends only the misses, in one call.
+// Items are keyed under the multimodal model, so a text embedded here never
+// collides with the same text embedded by the text model.
+func (c *CachedEmbedder) EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = c.key("mm_"+item.contentKind(), item.contentValue())
+	}
+	return c.many(ctx, keys, c.multimodalModel(), func(missIdx []int) (*openai.CreateEmbeddingResponse, error) {
+		miss := make([]ContentItem, len(missIdx))
+		for i, j := range missIdx {
+			miss[i] = items[j]
+		}
+		return c.inner.EmbedMultimodal(ctx, miss)
+	})
+}
+
+// many serves a multi-vector request: hits come from the cache, the misses
+// are embedded by one fetch call and written back
+func (c *CachedEmbedder) many(ctx context.Context, keys []string, model string, fetch func(missIdx []int) (*openai.CreateEmbeddingResponse, error)) (*openai.CreateEmbeddingResponse, error) {
+	vectors := make([][]float64, len(keys))
+	var missIdx []int
+	for i, key := range keys {
+		if vec, ok := c.get(ctx, key); ok {
+			vectors[i] = vec
+			continue
+		}
+		missIdx = append(missIdx, i)
+	}
+
+	var usage openai.CreateEmbeddingResponseUsage
+	if len(missIdx) > 0 {
+		res, err := fetch(missIdx)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Data) != len(missIdx) {
+			return nil, fmt.Errorf("embedding returned %d vectors for %d inputs", len(res.Data), len(missIdx))
+		}
+		for _, d := range res.Data {
+			if d.Index < 0 || int(d.Index) >= len(missIdx) {
+				return nil, fmt.Errorf("embedding returned out of range index %d", d.Index)
+			}
+			i := missIdx[d.Index]
+			vectors[i] = d.Embedding
+			c.set(ctx, keys[i], d.Embedding)
+		}
+		usage = res.Usage
+		model = res.Model
+	}
+	return cachedResponse(model, vectors, usage), nil
+}
+
+// single serves a one-vector request from the cache or fn
+func (c *CachedEmbedder) single(ctx context.Context, key string, fn func() (*openai.CreateEmbeddingResponse, error)) (*openai.CreateEmbeddingResponse, error) {
+	if vec, ok := c.get(ctx, key); ok {
+		return cachedResponse(c.multimodalModel(), [][]float64{vec}, openai.CreateEmbeddingResponseUsage{}), nil
+	}
+	res, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Data) == 1 {
+		c.set(ctx, key, res.Data[0].Embedding)
+	}
+	return res, nil
+}
+
+func (c *CachedEmbedder) get(ctx context.Context, key string) ([]float64, bool) {
+	vec, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		zlog.L().Warn("embedding cache get failed", zap.Error(err))
+	}
+	if ok {
+		c.hits.Add(1)
+		return vec, true
+	}
+	c.misses.Add(1)
+	return nil, false
+}
+
+func (c *CachedEmbedder) set(ctx context.Context, key string, vec []float64) {
+	if err := c.cache.Set(ctx, key, vec, c.ttl); err != nil {
+		zlog.L().Warn("embedding cache set failed", zap.Error(err))
+	}
+}
+
+func (c *CachedEmbedder) textModel() string {
+	if m, ok := c.inner.(modelReporter); ok {
+		return m.TextModel()
+	}
+	return fmt.Sprintf("%T", c.inner)
+}
+
+func (c *CachedEmbedder) multimodalModel() string {
+	if m, ok := c.inner.(modelReporter); ok {
+		return m.MultimodalModel()
+	}
+	return fmt.Sprintf("%T", c.inner)
+}
+
+// key hashes (model, dimensions, modality, input) into a fixed size cache key
+func (c *CachedEmbedder) key(kind, input string) string {
+	model := c.textModel()
+	if kind != "text" {
+		model = c.multimodalModel()
+	}
+	h := sha256.New()
+	for _, part := range []string{model, strconv.Itoa(c.inner.Dimensions()), kind, input} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "emb:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeText trims and collapses whitespace runs to a single space
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func cachedResponse(model string, vectors [][]float64, usage openai.CreateEmbeddingResponseUsage) *openai.CreateEmbeddingResponse {
+	data := make([]openai.Embedding, len(vectors))
+	for i, v := range vectors {
+		data[i] = openai.Embedding{
+			Embedding: v,
+			Index:     int64(i),
+			Object:    "embedding",
+		}
+	}
+	return &openai.CreateEmbeddingResponse{
+		Data:   data,
+		Model:  model,
+		Object: "list",
+		Usage:  usage,
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultMemoryCacheCapacity is used when the configured capacity is unset
+const defaultMemoryCacheCapacity = 10000
+
+// MemoryCache is an in-process LRU Cache with optional per-entry TTL
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	key      string
+	vec      []float64
+	expireAt time.Time
+}
+
+// NewMemoryCache creates an LRU holding at most capacity vectors
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = defaultMemoryCacheCapacity
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns a copy of the vector for key and marks it recently used.
+// Vectors are copied in and out so callers cannot change cached entries.
+func (m *MemoryCache) Get(_ context.Context, key string) ([]float64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expireAt.IsZero() && m.now().After(entry.expireAt) {
+		m.remove(el)
+		return nil, false, nil
+	}
+	m.ll.MoveToFront(el)
+	return slices.Clone(entry.vec), true, nil
+}
+
+// Set stores vec, evicting the least recently used entry when full
+func (m *MemoryCache) Set(_ context.Context, key string, vec []float64, ttl time.Duration) error {
+	vec = slices.Clone(vec)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = m.now().Add(ttl)
+	}
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.vec = vec
+		entry.expireAt = expireAt
+		m.ll.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.ll.PushFront(&memoryEntry{key: key, vec: vec, expireAt: expireAt})
+	for m.ll.Len() > m.capacity {
+		m.remove(m.ll.Back())
+	}
+	return nil
+}
+
+// Len reports the number of cached vectors
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+func (m *MemoryCache) remove(el *list.Element) {
+	m.ll.Remove(el)
+	delete(m.items, el.Value.(*memoryEntry).key)
+}
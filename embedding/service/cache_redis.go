@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores vectors in Redis as little-endian float64 blobs,
+// so every service instance shares one cache
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCache wraps an existing client; prefix namespaces the keys
+func NewRedisCache(client *redis.Client, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get returns the vector for key
+func (r *RedisCache) Get(ctx context.Context, key string) ([]float64, bool, error) {
+	raw, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(raw)%8 != 0 {
+		return nil, false, fmt.Errorf("corrupt cached vector for %s: %d bytes", key, len(raw))
+	}
+	vec := make([]float64, len(raw)/8)
+	for i := range vec {
+		vec[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[i*8:]))
+	}
+	return vec, true, nil
+}
+
+// Set stores vec; ttl 0 keeps it until Redis evicts it
+func (r *RedisCache) Set(ctx context.Context, key string, vec []float64, ttl time.Duration) error {
+	raw := make([]byte, len(vec)*8)
+	for i, v := range vec {
+		binary.LittleEndian.PutUint64(raw[i*8:], math.Float64bits(v))
+	}
+	return r.client.Set(ctx, r.prefix+key, raw, ttl).Err()
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder 统计实际下发到后端的输入条数
+type countingEmbedder struct {
+	*FakeEmbedder
+	texts  int32
+	images int32
+}
+
+func (c *countingEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	atomic.AddInt32(&c.texts, int32(len(texts)))
+	return c.FakeEmbedder.EmbedTexts(ctx, texts)
+}
+
+func (c *countingEmbedder) EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	atomic.AddInt32(&c.images, 1)
+	return c.FakeEmbedder.EmbedImage(ctx, imageURL)
+}
+
+// TestMemoryCacheLRU 测试LRU淘汰顺序
+func TestMemoryCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	require.NoError(t, c.Set(ctx, "a", []float64{1}, 0))
+	require.NoError(t, c.Set(ctx, "b", []float64{2}, 0))
+	_, ok, _ := c.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, c.Set(ctx, "c", []float64{3}, 0))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "b 最久未使用，应被淘汰")
+	_, ok, _ = c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+// TestMemoryCacheCopiesVectors 测试改动传入或取出的向量不影响缓存
+func TestMemoryCacheCopiesVectors(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	vec := []float64{1, 2}
+	require.NoError(t, c.Set(ctx, "k", vec, 0))
+	vec[0] = 9
+	got, ok, _ := c.Get(ctx, "k")
+	require.True(t, ok)
+	assert.Equal(t, []float64{1, 2}, got)
+
+	got[1] = 9
+	again, _, _ := c.Get(ctx, "k")
+	assert.Equal(t, []float64{1, 2}, again)
+}
+
+// TestMemoryCacheTTL 测试过期条目不再返回
+func TestMemoryCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "k", []float64{1}, time.Minute))
+	_, ok, _ := c.Get(ctx, "k")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok, _ = c.Get(ctx, "k")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+// TestCachedEmbedderHitsAndMisses 测试缓存命中只下发未命中的输入
+func TestCachedEmbedderHitsAndMisses(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{FakeEmbedder: NewFakeEmbedder(8)}
+	c := NewCachedEmbedder(inner, NewMemoryCache(100), time.Hour)
+
+	first, err := c.EmbedTexts(ctx, []string{"alpha", "beta"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), inner.texts)
+
+	// 空白差异归一化后命中缓存
+	second, err := c.EmbedTexts(ctx, []string{"  beta ", "gamma", "alpha"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), inner.texts, "只有 gamma 需要请求后端")
+	require.Len(t, second.Data, 3)
+	assert.Equal(t, first.Data[1].Embedding, second.Data[0].Embedding)
+	assert.Equal(t, first.Data[0].Embedding, second.Data[2].Embedding)
+	assert.Equal(t, int64(2), second.Data[2].Index)
+
+	_, err = c.EmbedImage(ctx, "https://example.com/a.jpg")
+	require.NoError(t, err)
+	_, err = c.EmbedImage(ctx, "https://example.com/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), inner.images)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+}
+
+// TestCachedEmbedderKeyIncludesDimensions 测试维度不同的模型不会共享缓存
+func TestCachedEmbedderKeyIncludesDimensions(t *testing.T) {
+	cache := NewMemoryCache(100)
+	a := NewCachedEmbedder(NewFakeEmbedder(8), cache, 0)
+	b := NewCachedEmbedder(NewFakeEmbedder(16), cache, 0)
+
+	assert.NotEqual(t, a.key("text", "x"), b.key("text", "x"))
+	assert.NotEqual(t, a.key("text", "x"), a.key("image", "x"))
+}
@@ -1,32 +1,134 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"sea/config"
-	"sea/zlog"
+	"strings"
+	"time"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
-	"go.uber.org/zap"
 )
 
 // Configuration constants moved to config.yaml for better management
 
+// ContentItem is one entry of a multimodal request.
+// Implemented by TextContent, ImageContent, VideoContent and MultiImageContent.
+type ContentItem interface {
+	// contentKind names the item type, matching its JSON key
+	contentKind() string
+	// contentValue is the normalized payload, used for cache keys
+	contentValue() string
+}
+
+// TextContent represents a text content item
+type TextContent struct {
+	Text string `json:"text"`
+}
+
 // ImageContent represents an image content item
 type ImageContent struct {
 	Image string `json:"image"`
 }
 
+// VideoContent represents a video content item
+type VideoContent struct {
+	Video string `json:"video"`
+}
+
 // MultiImageContent represents multiple image content
 type MultiImageContent struct {
 	MultiImages []string `json:"multi_images"`
 }
 
+func (c TextContent) contentKind() string       { return "text" }
+func (c TextContent) contentValue() string      { return normalizeText(c.Text) }
+func (c ImageContent) contentKind() string      { return "image" }
+func (c ImageContent) contentValue() string     { return strings.TrimSpace(c.Image) }
+func (c VideoContent) contentKind() string      { return "video" }
+func (c VideoContent) contentValue() string     { return strings.TrimSpace(c.Video) }
+func (c MultiImageContent) contentKind() string { return "multi_images" }
+func (c MultiImageContent) contentValue() string {
+	trimmed := make([]string, len(c.MultiImages))
+	for i, u := range c.MultiImages {
+		trimmed[i] = strings.TrimSpace(u)
+	}
+	return strings.Join(trimmed, "\n")
+}
+
+// ContentBuilder assembles mixed content items for one multimodal call,
+// e.g. an article title together with its cover image
+type ContentBuilder struct {
+	items []ContentItem
+}
+
+// NewContentBuilder starts an empty content list
+func NewContentBuilder() *ContentBuilder {
+	return &ContentBuilder{}
+}
+
+// Text appends a text item
+func (b *ContentBuilder) Text(text string) *ContentBuilder {
+	b.items = append(b.items, TextContent{Text: text})
+	return b
+}
+
+// Image appends an image URL item
+func (b *ContentBuilder) Image(url string) *ContentBuilder {
+	b.items = append(b.items, ImageContent{Image: url})
+	return b
+}
+
+// Video appends a video URL item
+func (b *ContentBuilder) Video(url string) *ContentBuilder {
+	b.items = append(b.items, VideoContent{Video: url})
+	return b
+}
+
+// MultiImages appends an item made of several images
+func (b *ContentBuilder) MultiImages(urls ...string) *ContentBuilder {
+	b.items = append(b.items, MultiImageContent{MultiImages: urls})
+	return b
+}
+
+// Items returns the assembled items in insertion order
+func (b *ContentBuilder) Items() []ContentItem {
+	return b.items
+}
+
+// parseContentItems decodes a JSON array such as
+// [{"text":"title"},{"image":"https://..."}] into content items
+func parseContentItems(data string) ([]ContentItem, error) {
+	var raw []struct {
+		Text        *string  `json:"text"`
+		Image       *string  `json:"image"`
+		Video       *string  `json:"video"`
+		MultiImages []string `json:"multi_images"`
+	}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+	items := make([]ContentItem, 0, len(raw))
+	for i, r := range raw {
+		switch {
+		case r.Text != nil:
+			items = append(items, TextContent{Text: *r.Text})
+		case r.Image != nil:
+			items = append(items, ImageContent{Image: *r.Image})
+		case r.Video != nil:
+			items = append(items, VideoContent{Video: *r.Video})
+		case r.MultiImages != nil:
+			items = append(items, MultiImageContent{MultiImages: r.MultiImages})
+		default:
+			return nil, fmt.Errorf("content item %d has none of text, image, video, multi_images", i)
+		}
+	}
+	return items, nil
+}
+
 // MultimodalInput represents the input structure for multimodal embedding
 type MultimodalInput struct {
 	Contents []interface{} `json:"contents"`
@@ -63,149 +165,114 @@ func getEmbeddingConfig() *config.AliConfig {
 
 // getTextClient returns a text embedding client
 func getTextClient() openai.Client {
-	cfg := &config.Cfg.Ali
-	return openai.NewClient(
-		option.WithAPIKey(cfg.APIKey),
-		option.WithBaseURL(cfg.BaseURL),
-	)
+	return newTextClient(config.Cfg.Ali.APIKey, config.Cfg.Ali.BaseURL)
 }
 
-// EmbeddingTxt creates text embedding using OpenAI SDK
-func EmbeddingTxt(txt string) (*openai.CreateEmbeddingResponse, error) {
-	cfg := getEmbeddingConfig()
-	client := getTextClient()
-	res, err := client.Embeddings.New(context.TODO(), openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfString: openai.String(txt),
-		},
-		Model:          cfg.TextModel,
-		Dimensions:     openai.Int(int64(cfg.Dimensions)),
-		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
-		User:           openai.String("user-neo"),
-	})
-	if err != nil {
-		zlog.L().Error("embedding text service fail", zap.Error(err))
-		return nil, fmt.Errorf("embedding text service fail: %w", err)
+// newTextClient returns an openai-go client for an OpenAI-compatible endpoint.
+// The SDK retries on its own; its retries pass the rate limit gate in ctx.
+func newTextClient(apiKey, baseURL string) openai.Client {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey), option.WithMiddleware(gateRetries)}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
 	}
-	return res, nil
+	return openai.NewClient(opts...)
 }
 
-// EmbeddingImage creates embedding from a single image URL using qwen2.5-vl-embedding
-func EmbeddingImage(imageURL string) (*openai.CreateEmbeddingResponse, error) {
-	aliConfig := getEmbeddingConfig()
-	req := MultimodalRequest{
-		Model: aliConfig.MultimodalModel,
-		Input: MultimodalInput{
-			Contents: []interface{}{ImageContent{Image: imageURL}},
-		},
-		Parameters: struct {
-			Dimension string `json:"dimension"`
-		}{
-			Dimension: fmt.Sprintf("%d", aliConfig.Dimensions),
-		},
+// gateRetries holds SDK retries, marked by a non-zero retry count header,
+// until the rate limit gate of the request context lets them through
+func gateRetries(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	if n := req.Header.Get("X-Stainless-Retry-Count"); n != "" && n != "0" {
+		if err := passRetryGate(req.Context()); err != nil {
+			return nil, err
+		}
 	}
-	return sendMultimodalRequest(req)
+	return next(req)
 }
 
-// EmbeddingMultiImages creates embedding from multiple image URLs using qwen2.5-vl-embedding
-func EmbeddingMultiImages(imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
-	aliConfig := getEmbeddingConfig()
-	req := MultimodalRequest{
-		Model: aliConfig.MultimodalModel,
-		Input: MultimodalInput{
-			Contents: []interface{}{MultiImageContent{MultiImages: imageURLs}},
-		},
-		Parameters: struct {
-			Dimension string `json:"dimension"`
-		}{
-			Dimension: fmt.Sprintf("%d", aliConfig.Dimensions),
-		},
+// withCallTimeout bounds ctx by the configured per-call timeout.
+// A deadline already set by the caller wins when it is earlier.
+func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return context.WithCancel(ctx)
 	}
-	return sendMultimodalRequest(req)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// EmbeddingTxt creates text embedding with the configured embedder
+func EmbeddingTxt(txt string) (*openai.CreateEmbeddingResponse, error) {
+	return EmbeddingTxtWithContext(context.Background(), txt)
+}
+
+// EmbeddingTxtWithContext is EmbeddingTxt bound to ctx
+func EmbeddingTxtWithContext(ctx context.Context, txt string) (*openai.CreateEmbeddingResponse, error) {
+	return Default().EmbedText(ctx, txt)
+}
+
+// EmbeddingImage creates embedding from a single image URL with the configured embedder
+func EmbeddingImage(imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	return EmbeddingImageWithContext(context.Background(), imageURL)
+}
+
+// EmbeddingImageWithContext is EmbeddingImage bound to ctx
+func EmbeddingImageWithContext(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	return Default().EmbedImage(ctx, imageURL)
+}
+
+// EmbeddingMultiImages creates embedding from multiple image URLs with the configured embedder
+func EmbeddingMultiImages(imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	return EmbeddingMultiImagesWithContext(context.Background(), imageURLs)
+}
+
+// EmbeddingMultiImagesWithContext is EmbeddingMultiImages bound to ctx
+func EmbeddingMultiImagesWithContext(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	return Default().EmbedMultiImages(ctx, imageURLs)
+}
+
+// EmbeddingMultimodal embeds mixed content items in one multimodal call.
+// The response holds one embedding per item, in item order.
+func EmbeddingMultimodal(items ...ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	return EmbeddingMultimodalWithContext(context.Background(), items...)
+}
+
+// EmbeddingMultimodalWithContext is EmbeddingMultimodal bound to ctx
+func EmbeddingMultimodalWithContext(ctx context.Context, items ...ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	return Default().EmbedMultimodal(ctx, items)
 }
 
 // EmbeddingGraph maintains compatibility with original function signature
-// Now delegates to appropriate function based on content type
+// Now delegates to appropriate function based on content type.
+// For text and video, url carries the text or the video URL; for mixed it is
+// a JSON array of items, see parseContentItems.
 func EmbeddingGraph(ty string, url string) (*openai.CreateEmbeddingResponse, error) {
+	return EmbeddingGraphWithContext(context.Background(), ty, url)
+}
+
+// EmbeddingGraphWithContext is EmbeddingGraph bound to ctx
+func EmbeddingGraphWithContext(ctx context.Context, ty string, url string) (*openai.CreateEmbeddingResponse, error) {
 	switch ty {
 	case "image":
-		return EmbeddingImage(url)
+		return EmbeddingImageWithContext(ctx, url)
 	case "multi_images":
 		// For multi_images, url should be a JSON array string
 		var urls []string
 		if err := json.Unmarshal([]byte(url), &urls); err != nil {
 			return nil, fmt.Errorf("invalid multi_images URL format: %w", err)
 		}
-		return EmbeddingMultiImages(urls)
+		return EmbeddingMultiImagesWithContext(ctx, urls)
+	case "text":
+		return EmbeddingMultimodalWithContext(ctx, TextContent{Text: url})
+	case "video":
+		return EmbeddingMultimodalWithContext(ctx, VideoContent{Video: url})
+	case "mixed":
+		items, err := parseContentItems(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mixed content format: %w", err)
+		}
+		return EmbeddingMultimodalWithContext(ctx, items...)
 	default:
-		return nil, fmt.Errorf("unsupported content type: %s. Supported types: image, multi_images", ty)
-	}
-}
-
-// sendMultimodalRequest sends the HTTP request to the multimodal API and converts response
-func sendMultimodalRequest(req MultimodalRequest) (*openai.CreateEmbeddingResponse, error) {
-	aliConfig := getEmbeddingConfig()
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		zlog.L().Error("failed to marshal multimodal request", zap.Error(err))
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", aliConfig.MultimodalBaseURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		zlog.L().Error("failed to create multimodal request", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+aliConfig.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := httpClient.Do(httpReq)
-	if err != nil {
-		zlog.L().Error("failed to execute multimodal request", zap.Error(err))
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("unsupported content type: %s. Supported types: image, multi_images, text, video, mixed", ty)
 	}
-	defer httpResp.Body.Close()
-
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		zlog.L().Error("failed to read multimodal response body", zap.Error(err))
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if httpResp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("API returned error status: %d, body: %s", httpResp.StatusCode, string(body))
-		zlog.L().Error("multimodal API error", zap.Error(err))
-		return nil, err
-	}
-
-	// Parse raw response
-	var raw rawMultimodalResponse
-	err = json.Unmarshal(body, &raw)
-	if err != nil {
-		zlog.L().Error("failed to unmarshal multimodal response", zap.Error(err))
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	// Convert to openai.CreateEmbeddingResponse format
-	embeddings := make([]openai.Embedding, 0, len(raw.Output.Embeddings))
-	for _, emb := range raw.Output.Embeddings {
-		embeddings = append(embeddings, openai.Embedding{
-			Embedding: emb.Embedding,
-			Index:     int64(emb.Index),
-			Object:    "embedding",
-		})
-	}
-
-	response := &openai.CreateEmbeddingResponse{
-		Data:   embeddings,
-		Model:  aliConfig.MultimodalModel,
-		Object: "list",
-		Usage: openai.CreateEmbeddingResponseUsage{
-			PromptTokens: raw.Usage.TotalTokens,
-			TotalTokens:  raw.Usage.TotalTokens,
-		},
-	}
-
-	return response, nil
 }
@@ -1,10 +1,16 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"sea/config"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -259,8 +265,8 @@ func TestEmbeddingGraphTypeHandling(t *testing.T) {
 		},
 		{
 			name:       "不支持的类型",
-			ty:         "video",
-			url:        "https://example.com/video.mp4",
+			ty:         "audio",
+			url:        "https://example.com/audio.mp3",
 			expectErr:  true,
 			expectType: "",
 		},
@@ -389,6 +395,141 @@ func TestEmbeddingGraphJSONParsing(t *testing.T) {
 	}
 }
 
+// TestEmbeddingImageWithContextCanceled 测试调用方取消后多模态请求立即返回
+func TestEmbeddingImageWithContextCanceled(t *testing.T) {
+	setupTestConfig(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	config.Cfg.Ali.MultimodalBaseURL = server.URL
+	config.Cfg.Ali.Timeout = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := EmbeddingImageWithContext(ctx, "https://example.com/image.jpg")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+// TestEmbeddingImageConfiguredTimeout 测试配置的单次调用超时生效
+func TestEmbeddingImageConfiguredTimeout(t *testing.T) {
+	setupTestConfig(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	config.Cfg.Ali.MultimodalBaseURL = server.URL
+	config.Cfg.Ali.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := EmbeddingImageWithContext(context.Background(), "https://example.com/image.jpg")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestWithCallTimeout 测试调用方更早的截止时间优先
+func TestWithCallTimeout(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, done := withCallTimeout(parent, time.Hour)
+	defer done()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	ctx, done = withCallTimeout(context.Background(), 0)
+	defer done()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+// TestMixedContentRequest 测试文本+图片混合请求的构造与下发
+func TestMixedContentRequest(t *testing.T) {
+	setupTestConfig(t)
+
+	var got MultimodalRequest
+	var raw map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_ = json.Unmarshal(body, &raw)
+		_, _ = w.Write([]byte(`{"output":{"embeddings":[{"index":0,"embedding":[0.1]},{"index":1,"embedding":[0.2]}]},"usage":{"total_tokens":9}}`))
+	}))
+	defer server.Close()
+	config.Cfg.Ali.MultimodalBaseURL = server.URL
+	config.Cfg.Ali.Timeout = 0
+
+	items := NewContentBuilder().
+		Text("文章标题").
+		Image("https://example.com/cover.jpg").
+		Items()
+	res, err := EmbeddingMultimodalWithContext(context.Background(), items...)
+	require.NoError(t, err)
+	require.Len(t, res.Data, 2)
+
+	assert.Equal(t, "qwen2.5-vl-embedding", got.Model)
+	contents := raw["input"].(map[string]interface{})["contents"].([]interface{})
+	require.Len(t, contents, 2)
+	assert.Equal(t, "文章标题", contents[0].(map[string]interface{})["text"])
+	assert.Equal(t, "https://example.com/cover.jpg", contents[1].(map[string]interface{})["image"])
+}
+
+// TestParseContentItems 测试mixed类型的JSON解析
+func TestParseContentItems(t *testing.T) {
+	items, err := parseContentItems(`[{"text":"标题"},{"image":"a.jpg"},{"video":"b.mp4"},{"multi_images":["c.jpg","d.jpg"]}]`)
+	require.NoError(t, err)
+	assert.Equal(t, []ContentItem{
+		TextContent{Text: "标题"},
+		ImageContent{Image: "a.jpg"},
+		VideoContent{Video: "b.mp4"},
+		MultiImageContent{MultiImages: []string{"c.jpg", "d.jpg"}},
+	}, items)
+
+	_, err = parseContentItems(`[{"audio":"x.mp3"}]`)
+	assert.Error(t, err)
+	_, err = parseContentItems(`not json`)
+	assert.Error(t, err)
+}
+
+// TestEmbeddingGraphMixedWithFake 测试EmbeddingGraph新增类型走配置的后端
+func TestEmbeddingGraphMixedWithFake(t *testing.T) {
+	setupTestConfig(t)
+	config.Cfg.Embedding.Provider = ProviderFake
+	t.Cleanup(func() { config.Cfg.Embedding.Provider = "" })
+
+	res, err := EmbeddingGraph("mixed", `[{"text":"标题"},{"image":"https://example.com/a.jpg"}]`)
+	require.NoError(t, err)
+	require.Len(t, res.Data, 2)
+
+	img, err := EmbeddingGraph("image", "https://example.com/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, img.Data[0].Embedding, res.Data[1].Embedding)
+
+	_, err = EmbeddingGraph("video", "https://example.com/a.mp4")
+	assert.NoError(t, err)
+	_, err = EmbeddingGraph("text", "标题")
+	assert.NoError(t, err)
+}
+
 // 辅助函数
 
 // setupTestConfig 设置测试配置
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sea/config"
+	"sea/zlog"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"go.uber.org/zap"
+)
+
+// DashScopeEmbedder talks to Aliyun DashScope: the compatible-mode endpoint
+// for text and the native multimodal endpoint for images.
+type DashScopeEmbedder struct {
+	cfg        config.AliConfig
+	text       openai.Client
+	httpClient *http.Client
+}
+
+// NewDashScopeEmbedder creates a DashScope backed embedder
+func NewDashScopeEmbedder(cfg config.AliConfig) *DashScopeEmbedder {
+	return &DashScopeEmbedder{
+		cfg:        cfg,
+		text:       newTextClient(cfg.APIKey, cfg.BaseURL),
+		httpClient: httpClient,
+	}
+}
+
+// Dimensions reports the configured output dimension
+func (e *DashScopeEmbedder) Dimensions() int {
+	return e.cfg.Dimensions
+}
+
+// EmbedText creates text embedding using OpenAI SDK
+func (e *DashScopeEmbedder) EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error) {
+	return e.embedText(ctx, openai.EmbeddingNewParamsInputUnion{
+		OfString: openai.String(text),
+	})
+}
+
+// EmbedTexts creates embeddings for several texts in one request
+func (e *DashScopeEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	return e.embedText(ctx, openai.EmbeddingNewParamsInputUnion{
+		OfArrayOfStrings: texts,
+	})
+}
+
+func (e *DashScopeEmbedder) embedText(ctx context.Context, input openai.EmbeddingNewParamsInputUnion) (*openai.CreateEmbeddingResponse, error) {
+	ctx, cancel := withCallTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	res, err := e.text.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input:          input,
+		Model:          e.cfg.TextModel,
+		Dimensions:     openai.Int(int64(e.cfg.Dimensions)),
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+		User:           openai.String("user-neo"),
+	})
+	if err != nil {
+		err = fromOpenAIError("dashscope", err)
+		zlog.L().Error("embedding text service fail", zap.Error(err))
+		return nil, fmt.Errorf("embedding text service fail: %w", err)
+	}
+	return res, nil
+}
+
+// EmbedImage creates embedding from a single image URL using qwen2.5-vl-embedding
+func (e *DashScopeEmbedder) EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	return e.sendMultimodalRequest(ctx, e.newMultimodalRequest(ImageContent{Image: imageURL}))
+}
+
+// EmbedMultiImages creates embedding from multiple image URLs using qwen2.5-vl-embedding
+func (e *DashScopeEmbedder) EmbedMultiImages(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	return e.sendMultimodalRequest(ctx, e.newMultimodalRequest(MultiImageContent{MultiImages: imageURLs}))
+}
+
+// EmbedMultimodal sends mixed text, image and video items in one request
+func (e *DashScopeEmbedder) EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyInput
+	}
+	contents := make([]interface{}, len(items))
+	for i, item := range items {
+		contents[i] = item
+	}
+	return e.sendMultimodalRequest(ctx, e.newMultimodalRequest(contents...))
+}
+
+// newMultimodalRequest wraps contents with the configured model and dimension
+func (e *DashScopeEmbedder) newMultimodalRequest(contents ...interface{}) MultimodalRequest {
+	req := MultimodalRequest{
+		Model: e.cfg.MultimodalModel,
+		Input: MultimodalInput{
+			Contents: contents,
+		},
+	}
+	req.Parameters.Dimension = fmt.Sprintf("%d", e.cfg.Dimensions)
+	return req
+}
+
+// sendMultimodalRequest sends the HTTP request to the multimodal API and converts response.
+// Throttling, 5xx and transport failures are retried per the ali.retry config.
+func (e *DashScopeEmbedder) sendMultimodalRequest(ctx context.Context, req MultimodalRequest) (*openai.CreateEmbeddingResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		zlog.L().Error("failed to marshal multimodal request", zap.Error(err))
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	body, err := withRetry(ctx, newRetryPolicy(e.cfg.Retry), func(ctx context.Context) ([]byte, *attemptError) {
+		return e.postMultimodal(ctx, jsonData)
+	})
+	if err != nil {
+		zlog.L().Error("multimodal API error", zap.Error(err))
+		return nil, fmt.Errorf("multimodal request: %w", err)
+	}
+
+	// Parse raw response
+	var raw rawMultimodalResponse
+	err = json.Unmarshal(body, &raw)
+	if err != nil {
+		zlog.L().Error("failed to unmarshal multimodal response", zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// Convert to openai.CreateEmbeddingResponse format
+	embeddings := make([]openai.Embedding, 0, len(raw.Output.Embeddings))
+	for _, emb := range raw.Output.Embeddings {
+		embeddings = append(embeddings, openai.Embedding{
+			Embedding: emb.Embedding,
+			Index:     int64(emb.Index),
+			Object:    "embedding",
+		})
+	}
+
+	response := &openai.CreateEmbeddingResponse{
+		Data:   embeddings,
+		Model:  e.cfg.MultimodalModel,
+		Object: "list",
+		Usage: openai.CreateEmbeddingResponseUsage{
+			PromptTokens: raw.Usage.TotalTokens,
+			TotalTokens:  raw.Usage.TotalTokens,
+		},
+	}
+
+	return response, nil
+}
+
+// postMultimodal performs one attempt bounded by the per-call timeout
+func (e *DashScopeEmbedder) postMultimodal(parent context.Context, jsonData []byte) ([]byte, *attemptError) {
+	ctx, cancel := withCallTimeout(parent, e.cfg.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", e.cfg.MultimodalBaseURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, &attemptError{err: fmt.Errorf("failed to create request: %w", err)}
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		zlog.L().Warn("failed to execute multimodal request", zap.Error(err))
+		return nil, &attemptError{
+			err:       fmt.Errorf("failed to execute request: %w", err),
+			retryable: retryableTransportError(parent, err),
+		}
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, &attemptError{
+			err:       fmt.Errorf("failed to read response body: %w", err),
+			retryable: retryableTransportError(parent, err),
+		}
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		apiErr := newDashScopeError(httpResp.StatusCode, httpResp.Header, body)
+		aerr := &attemptError{
+			err:       apiErr,
+			retryable: isRetryableAPIError(apiErr) || (!isPermanentAPIError(apiErr) && retryableStatus(httpResp.StatusCode)),
+		}
+		if d, ok := parseRetryAfter(httpResp.Header, time.Now()); ok {
+			aerr.retryAfter = d
+		}
+		zlog.L().Warn("multimodal API returned error status",
+			zap.Int("status", httpResp.StatusCode),
+			zap.Bool("retryable", aerr.retryable))
+		return nil, aerr
+	}
+	return body, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sea/config"
+	"strings"
+	"sync"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// Supported values for embedding.provider in config.yaml
+const (
+	ProviderDashScope = "dashscope"
+	ProviderOpenAI    = "openai"
+	ProviderFake      = "fake"
+)
+
+// ErrUnsupported is returned when a backend cannot embed the requested modality
+var ErrUnsupported = errors.New("embedding: operation not supported by provider")
+
+// Embedder is implemented by every embedding backend.
+// All methods return results in the openai.CreateEmbeddingResponse shape,
+// with Data ordered by input index.
+type Embedder interface {
+	// EmbedText embeds a single text
+	EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error)
+	// EmbedTexts embeds several texts in one provider call
+	EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error)
+	// EmbedImage embeds a single image URL
+	EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error)
+	// EmbedMultiImages embeds several image URLs as one item
+	EmbedMultiImages(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error)
+	// EmbedMultimodal embeds mixed content items, one embedding per item
+	EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error)
+	// Dimensions reports the vector length produced by the backend
+	Dimensions() int
+}
+
+// NewEmbedder builds the embedder selected by cfg.Embedding.Provider.
+// An empty provider falls back to DashScope.
+func NewEmbedder(cfg config.Config) (Embedder, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Embedding.Provider)) {
+	case "", ProviderDashScope:
+		return NewDashScopeEmbedder(cfg.Ali), nil
+	case ProviderOpenAI:
+		return NewOpenAIEmbedder(cfg.Embedding.OpenAI), nil
+	case ProviderFake:
+		return NewFakeEmbedder(cfg.EmbeddingDimensions()), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s. Supported providers: dashscope, openai, fake", cfg.Embedding.Provider)
+	}
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultEmbedder Embedder
+	// defaultUncached is defaultEmbedder without the cache layer
+	defaultUncached Embedder
+	closers         []func() error
+)
+
+// Init builds the process wide embedder from cfg and makes it the one
+// returned by Default. Calls go cache -> rate limiter -> provider, so cache
+// hits never spend quota. Call Close on shutdown.
+func Init(cfg config.Config) error {
+	e, err := NewEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Embedding.RateLimits) > 0 {
+		e = NewRateLimitedEmbedder(e, NewRateLimiter(cfg.Embedding.RateLimits))
+	}
+
+	uncached := e
+	var cleanup []func() error
+	cacheCfg := cfg.Embedding.Cache
+	if cacheCfg.Enabled {
+		var cache Cache
+		switch strings.ToLower(cacheCfg.Backend) {
+		case "", "memory":
+			cache = NewMemoryCache(cacheCfg.Capacity)
+		case "redis":
+			client := redis.NewClient(&redis.Options{
+				Addr:     cfg.Redis.Address,
+				Password: cfg.Redis.Password,
+				DB:       cfg.Redis.DB,
+			})
+			cleanup = append(cleanup, client.Close)
+			cache = NewRedisCache(client, cacheCfg.KeyPrefix)
+		default:
+			return fmt.Errorf("unsupported embedding cache backend: %s. Supported backends: memory, redis", cacheCfg.Backend)
+		}
+		e = NewCachedEmbedder(e, cache, cacheCfg.TTL)
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultEmbedder = e
+	defaultUncached = uncached
+	closers = cleanup
+	return nil
+}
+
+// Close releases resources opened by Init
+func Close() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c())
+	}
+	closers = nil
+	defaultEmbedder = nil
+	defaultUncached = nil
+	return errors.Join(errs...)
+}
+
+// Default returns the embedder set up by Init. Before Init it builds a
+// plain embedder from config.Cfg on every call.
+// An unknown provider yields an embedder that fails every call.
+func Default() Embedder {
+	defaultMu.RLock()
+	e := defaultEmbedder
+	defaultMu.RUnlock()
+	if e != nil {
+		return e
+	}
+	e, err := NewEmbedder(config.Cfg)
+	if err != nil {
+		return failingEmbedder{err: err}
+	}
+	return e
+}
+
+// Uncached returns the embedder set up by Init minus the cache, so every
+// call reaches the provider; health probes use it. Before Init it is
+// Default.
+func Uncached() Embedder {
+	defaultMu.RLock()
+	e := defaultUncached
+	defaultMu.RUnlock()
+	if e != nil {
+		return e
+	}
+	return Default()
+}
+
+// DefaultCacheStats reports the cache counters of the embedder set up by
+// Init; ok is false when the cache is disabled or Init has not run
+func DefaultCacheStats() (stats CacheStats, ok bool) {
+	defaultMu.RLock()
+	e := defaultEmbedder
+	defaultMu.RUnlock()
+	c, ok := e.(*CachedEmbedder)
+	if !ok {
+		return CacheStats{}, false
+	}
+	return c.Stats(), true
+}
+
+// failingEmbedder surfaces a configuration error on every call
+type failingEmbedder struct {
+	err error
+}
+
+func (f failingEmbedder) EmbedText(context.Context, string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, f.err
+}
+
+func (f failingEmbedder) EmbedTexts(context.Context, []string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, f.err
+}
+
+func (f failingEmbedder) EmbedImage(context.Context, string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, f.err
+}
+
+func (f failingEmbedder) EmbedMultiImages(context.Context, []string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, f.err
+}
+
+func (f failingEmbedder) EmbedMultimodal(context.Context, []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	return nil, f.err
+}
+
+func (f failingEmbedder) Dimensions() int {
+	return 0
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sea/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewEmbedderProviderSelection 测试根据配置选择后端
+func TestNewEmbedderProviderSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  string
+		expectErr bool
+		check     func(t *testing.T, e Embedder)
+	}{
+		{
+			name:     "默认使用DashScope",
+			provider: "",
+			check: func(t *testing.T, e Embedder) {
+				_, ok := e.(*DashScopeEmbedder)
+				assert.True(t, ok)
+			},
+		},
+		{
+			name:     "OpenAI兼容",
+			provider: "openai",
+			check: func(t *testing.T, e Embedder) {
+				_, ok := e.(*OpenAIEmbedder)
+				assert.True(t, ok)
+			},
+		},
+		{
+			name:     "本地假实现",
+			provider: "Fake",
+			check: func(t *testing.T, e Embedder) {
+				_, ok := e.(*FakeEmbedder)
+				assert.True(t, ok)
+				assert.Equal(t, 16, e.Dimensions())
+			},
+		},
+		{
+			name:      "未知后端",
+			provider:  "unknown",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{}
+			cfg.Embedding.Provider = tt.provider
+			cfg.Embedding.Fake.Dimensions = 16
+
+			e, err := NewEmbedder(cfg)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, e)
+		})
+	}
+}
+
+// TestFakeEmbedderDeterministic 测试假实现的确定性和归一化
+func TestFakeEmbedderDeterministic(t *testing.T) {
+	e := NewFakeEmbedder(64)
+	ctx := context.Background()
+
+	a, err := e.EmbedText(ctx, "你好")
+	require.NoError(t, err)
+	b, err := e.EmbedText(ctx, "你好")
+	require.NoError(t, err)
+	c, err := e.EmbedText(ctx, "world")
+	require.NoError(t, err)
+
+	require.Len(t, a.Data, 1)
+	assert.Len(t, a.Data[0].Embedding, 64)
+	assert.Equal(t, a.Data[0].Embedding, b.Data[0].Embedding)
+	assert.NotEqual(t, a.Data[0].Embedding, c.Data[0].Embedding)
+
+	var norm float64
+	for _, v := range a.Data[0].Embedding {
+		norm += v * v
+	}
+	assert.InDelta(t, 1.0, math.Sqrt(norm), 1e-9)
+
+	// 批量结果与单条结果一致，且按输入顺序返回
+	batch, err := e.EmbedTexts(ctx, []string{"world", "你好"})
+	require.NoError(t, err)
+	require.Len(t, batch.Data, 2)
+	assert.Equal(t, c.Data[0].Embedding, batch.Data[0].Embedding)
+	assert.Equal(t, a.Data[0].Embedding, batch.Data[1].Embedding)
+	assert.Equal(t, int64(1), batch.Data[1].Index)
+
+	// 文本与图片不会碰撞
+	img, err := e.EmbedImage(ctx, "你好")
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Data[0].Embedding, img.Data[0].Embedding)
+}
+
+// TestOpenAIEmbedderRejectsImages 测试OpenAI兼容后端不支持图片
+func TestOpenAIEmbedderRejectsImages(t *testing.T) {
+	e := NewOpenAIEmbedder(config.OpenAIConfig{})
+
+	_, err := e.EmbedImage(context.Background(), "https://example.com/image.jpg")
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	_, err = e.EmbedMultiImages(context.Background(), []string{"https://example.com/image.jpg"})
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+// TestUncachedSkipsCache 测试 Uncached 绕过缓存层
+func TestUncachedSkipsCache(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Embedding.Provider = "fake"
+	cfg.Embedding.Fake.Dimensions = 4
+	cfg.Embedding.Cache.Enabled = true
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Close() })
+
+	_, cached := Default().(*CachedEmbedder)
+	assert.True(t, cached)
+	_, fake := Uncached().(*FakeEmbedder)
+	assert.True(t, fake)
+}
+
+// TestDefaultCacheStats 测试 Init 开启缓存后可以读到命中统计
+func TestDefaultCacheStats(t *testing.T) {
+	t.Cleanup(func() { _ = Close() })
+	_, ok := DefaultCacheStats()
+	assert.False(t, ok)
+
+	var cfg config.Config
+	cfg.Embedding.Provider = ProviderFake
+	cfg.Embedding.Fake.Dimensions = 8
+	cfg.Embedding.Cache.Enabled = true
+	cfg.Embedding.Cache.Capacity = 10
+	require.NoError(t, Init(cfg))
+
+	for range 2 {
+		_, err := Default().EmbedText(context.Background(), "alpha")
+		require.NoError(t, err)
+	}
+	stats, ok := DefaultCacheStats()
+	require.True(t, ok)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, stats)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// APIError carries the details an embedding provider returned for a failed call.
+// Failures are further classified into RateLimitError, QuotaError, AuthError,
+// InvalidInputError, ModerationError and ServerError; each of them unwraps
+// to the *APIError, so errors.As works for both the kind and the details.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s API error: status %d", e.Provider, e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, ", code %s", e.Code)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, ", request_id %s", e.RequestID)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	return b.String()
+}
+
+// RateLimitError reports throttling of the request or token rate
+type RateLimitError struct{ *APIError }
+
+func (e *RateLimitError) Unwrap() error { return e.APIError }
+
+// QuotaError reports exhausted quota or an account in arrears; unlike
+// throttling it does not go away by retrying
+type QuotaError struct{ *APIError }
+
+func (e *QuotaError) Unwrap() error { return e.APIError }
+
+// AuthError reports a missing, invalid or unauthorized API key,
+// or an account that is not allowed to call the model
+type AuthError struct{ *APIError }
+
+func (e *AuthError) Unwrap() error { return e.APIError }
+
+// InvalidInputError reports a request the provider rejected as malformed,
+// e.g. an unreachable image URL or an input that is too long
+type InvalidInputError struct{ *APIError }
+
+func (e *InvalidInputError) Unwrap() error { return e.APIError }
+
+// ModerationError reports input refused by content inspection
+type ModerationError struct{ *APIError }
+
+func (e *ModerationError) Unwrap() error { return e.APIError }
+
+// ServerError reports a provider side failure
+type ServerError struct{ *APIError }
+
+func (e *ServerError) Unwrap() error { return e.APIError }
+
+// classifyAPIError wraps base into its kind, using the provider code first
+// and falling back to the HTTP status
+func classifyAPIError(base *APIError) error {
+	code := strings.ToLower(base.Code)
+	switch {
+	case code == "insufficient_quota", code == "arrearage":
+		return &QuotaError{base}
+	case strings.HasPrefix(code, "throttling"), code == "rate_limit_exceeded":
+		return &RateLimitError{base}
+	case code == "invalidapikey", code == "invalid_api_key", code == "accessdenied",
+		strings.HasPrefix(code, "accessdenied."):
+		return &AuthError{base}
+	case code == "datainspectionfailed", code == "data_inspection_failed", code == "content_filter":
+		return &ModerationError{base}
+	case code == "internalerror", strings.HasPrefix(code, "internalerror."), code == "server_error":
+		return &ServerError{base}
+	case code == "invalidparameter", strings.HasPrefix(code, "invalidparameter."), code == "invalid_request_error":
+		return &InvalidInputError{base}
+	}
+
+	switch {
+	case base.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{base}
+	case base.StatusCode == http.StatusUnauthorized || base.StatusCode == http.StatusForbidden:
+		return &AuthError{base}
+	case base.StatusCode >= 500:
+		return &ServerError{base}
+	case base.StatusCode >= 400:
+		return &InvalidInputError{base}
+	}
+	return base
+}
+
+// dashScopeErrorBody is the error envelope of DashScope native endpoints
+type dashScopeErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// newDashScopeError parses a DashScope error response into a typed error.
+// A body that is not the documented envelope is kept verbatim as Message.
+func newDashScopeError(status int, header http.Header, body []byte) error {
+	base := &APIError{
+		Provider:   "dashscope",
+		StatusCode: status,
+	}
+	var parsed dashScopeErrorBody
+	if err := json.Unmarshal(body, &parsed); err == nil && (parsed.Code != "" || parsed.Message != "") {
+		base.Code = parsed.Code
+		base.Message = parsed.Message
+		base.RequestID = parsed.RequestID
+	} else {
+		base.Message = strings.TrimSpace(string(body))
+	}
+	if base.RequestID == "" && header != nil {
+		base.RequestID = header.Get("X-Request-Id")
+	}
+	return classifyAPIError(base)
+}
+
+// fromOpenAIError maps openai-go errors on the text path onto the same kinds.
+// Errors that did not come from the API (network, context) pass through.
+func fromOpenAIError(provider string, err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	base := &APIError{
+		Provider:   provider,
+		StatusCode: apiErr.StatusCode,
+		Code:       apiErr.Code,
+		Message:    apiErr.Message,
+	}
+	if base.Code == "" {
+		base.Code = apiErr.Type
+	}
+	if apiErr.Response != nil {
+		base.RequestID = apiErr.Response.Header.Get("X-Request-Id")
+	}
+	return classifyAPIError(base)
+}
+
+// isRetryableAPIError reports error kinds worth another attempt
+func isRetryableAPIError(err error) bool {
+	var rl *RateLimitError
+	var se *ServerError
+	return errors.As(err, &rl) || errors.As(err, &se)
+}
+
+// isPermanentAPIError reports error kinds that no retry can fix, whatever
+// the HTTP status says
+func isPermanentAPIError(err error) bool {
+	var q *QuotaError
+	return errors.As(err, &q)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sea/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewDashScopeErrorClassification 测试DashScope错误体映射为类型化错误
+func TestNewDashScopeErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(err error) bool
+	}{
+		{
+			name:   "限流",
+			status: http.StatusTooManyRequests,
+			body:   `{"code":"Throttling.RateQuota","message":"Requests rate limit exceeded","request_id":"r-1"}`,
+			check:  func(err error) bool { var e *RateLimitError; return errors.As(err, &e) },
+		},
+		{
+			name:   "欠费",
+			status: http.StatusBadRequest,
+			body:   `{"code":"Arrearage","message":"Access denied, please make sure your account is in good standing.","request_id":"r-6"}`,
+			check:  func(err error) bool { var e *QuotaError; return errors.As(err, &e) },
+		},
+		{
+			name:   "额度用尽不算限流",
+			status: http.StatusTooManyRequests,
+			body:   `{"code":"insufficient_quota","message":"You exceeded your current quota.","request_id":"r-7"}`,
+			check: func(err error) bool {
+				var q *QuotaError
+				return errors.As(err, &q) && !isRetryableAPIError(err)
+			},
+		},
+		{
+			name:   "鉴权失败",
+			status: http.StatusUnauthorized,
+			body:   `{"code":"InvalidApiKey","message":"Invalid API-key provided.","request_id":"r-2"}`,
+			check:  func(err error) bool { var e *AuthError; return errors.As(err, &e) },
+		},
+		{
+			name:   "无效图片地址",
+			status: http.StatusBadRequest,
+			body:   `{"code":"InvalidParameter","message":"Download the media resource timed out","request_id":"r-3"}`,
+			check:  func(err error) bool { var e *InvalidInputError; return errors.As(err, &e) },
+		},
+		{
+			name:   "内容审核",
+			status: http.StatusBadRequest,
+			body:   `{"code":"DataInspectionFailed","message":"Input data may contain inappropriate content.","request_id":"r-4"}`,
+			check:  func(err error) bool { var e *ModerationError; return errors.As(err, &e) },
+		},
+		{
+			name:   "服务端错误",
+			status: http.StatusInternalServerError,
+			body:   `{"code":"InternalError","message":"internal error","request_id":"r-5"}`,
+			check:  func(err error) bool { var e *ServerError; return errors.As(err, &e) },
+		},
+		{
+			name:   "非JSON错误体按状态码归类",
+			status: http.StatusBadGateway,
+			body:   `<html>bad gateway</html>`,
+			check:  func(err error) bool { var e *ServerError; return errors.As(err, &e) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newDashScopeError(tt.status, http.Header{}, []byte(tt.body))
+			assert.True(t, tt.check(err), "unexpected kind: %T", err)
+
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, "dashscope", apiErr.Provider)
+		})
+	}
+}
+
+// TestMultimodalErrorCarriesDetails 测试多模态路径返回的错误可以取出code和request_id
+func TestMultimodalErrorCarriesDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"InvalidParameter","message":"url error","request_id":"req-42"}`))
+	}))
+	defer server.Close()
+
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "bad-url")
+	require.Error(t, err)
+
+	var invalid *InvalidInputError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, "InvalidParameter", invalid.Code)
+	assert.Equal(t, "req-42", invalid.RequestID)
+}
+
+// TestTextPathErrorMapping 测试文本路径的openai-go错误映射
+func TestTextPathErrorMapping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-text")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"code":"invalid_api_key","message":"Incorrect API key provided","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	e := NewDashScopeEmbedder(config.AliConfig{
+		APIKey:     "bad",
+		BaseURL:    server.URL,
+		TextModel:  "text-embedding-v4",
+		Dimensions: 4,
+	})
+	_, err := e.EmbedText(context.Background(), "hello")
+	require.Error(t, err)
+
+	var auth *AuthError
+	require.True(t, errors.As(err, &auth))
+	assert.Equal(t, "req-text", auth.RequestID)
+	assert.Equal(t, http.StatusUnauthorized, auth.StatusCode)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// fakeModel is reported as the model name of fake responses
+const fakeModel = "fake-hash-embedding"
+
+// FakeEmbedder returns deterministic unit vectors derived from a hash of the
+// input. Equal inputs always map to equal vectors, so tests and offline
+// development work without an API key.
+type FakeEmbedder struct {
+	dim int
+}
+
+// NewFakeEmbedder creates a fake embedder producing dim sized vectors
+func NewFakeEmbedder(dim int) *FakeEmbedder {
+	if dim <= 0 {
+		dim = 2048
+	}
+	return &FakeEmbedder{dim: dim}
+}
+
+// Dimensions reports the vector length
+func (e *FakeEmbedder) Dimensions() int {
+	return e.dim
+}
+
+// EmbedText embeds a single text
+func (e *FakeEmbedder) EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error) {
+	return e.EmbedTexts(ctx, []string{text})
+}
+
+// EmbedTexts embeds several texts
+func (e *FakeEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	inputs := make([]string, len(texts))
+	tokens := 0
+	for i, t := range texts {
+		inputs[i] = "text:" + t
+		tokens += utf8.RuneCountInString(t)
+	}
+	return e.response(inputs, tokens), nil
+}
+
+// EmbedImage embeds a single image URL
+func (e *FakeEmbedder) EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.response([]string{"image:" + imageURL}, 1), nil
+}
+
+// EmbedMultiImages embeds several image URLs as one item
+func (e *FakeEmbedder) EmbedMultiImages(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.response([]string{"multi_images:" + strings.Join(imageURLs, "\n")}, len(imageURLs)), nil
+}
+
+// EmbedMultimodal embeds each item separately. Image and multi image items
+// map to the same vectors as EmbedImage and EmbedMultiImages.
+func (e *FakeEmbedder) EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	inputs := make([]string, len(items))
+	for i, item := range items {
+		switch c := item.(type) {
+		case ImageContent:
+			inputs[i] = "image:" + c.Image
+		case MultiImageContent:
+			inputs[i] = "multi_images:" + strings.Join(c.MultiImages, "\n")
+		default:
+			inputs[i] = "mm_" + item.contentKind() + ":" + item.contentValue()
+		}
+	}
+	return e.response(inputs, len(items)), nil
+}
+
+func (e *FakeEmbedder) response(inputs []string, tokens int) *openai.CreateEmbeddingResponse {
+	data := make([]openai.Embedding, 0, len(inputs))
+	for i, in := range inputs {
+		data = append(data, openai.Embedding{
+			Embedding: e.vector(in),
+			Index:     int64(i),
+			Object:    "embedding",
+		})
+	}
+	return &openai.CreateEmbeddingResponse{
+		Data:   data,
+		Model:  fakeModel,
+		Object: "list",
+		Usage: openai.CreateEmbeddingResponseUsage{
+			PromptTokens: int64(tokens),
+			TotalTokens:  int64(tokens),
+		},
+	}
+}
+
+// vector expands sha256(input) with a splitmix64 stream and L2-normalizes it
+func (e *FakeEmbedder) vector(input string) []float64 {
+	sum := sha256.Sum256([]byte(input))
+	state := binary.LittleEndian.Uint64(sum[:8])
+	vec := make([]float64, e.dim)
+	var norm float64
+	for i := range vec {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		z ^= z >> 31
+		v := float64(z>>11)/float64(1<<53)*2 - 1
+		vec[i] = v
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for i := range vec {
+			vec[i] /= norm
+		}
+	}
+	return vec
+}
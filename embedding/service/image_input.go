@@ -0,0 +1,294 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"sea/config"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Defaults used when ali.image leaves a limit unset
+const (
+	// DashScope rejects inline images above 3 MB
+	defaultImageMaxBytes = 3 << 20
+	// Refuse to decode anything larger than 50 megapixels
+	maxDecodePixels = 50_000_000
+	jpegQuality     = 90
+	// shrinking stops once the shorter side would drop below this
+	minShrinkDimension = 32
+)
+
+// shrinkQualities are the JPEG qualities tried at each size while shrinking
+var shrinkQualities = []int{80, 65, 50}
+
+var (
+	// ErrUnsupportedImage is returned for bytes that are not a known image format
+	ErrUnsupportedImage = errors.New("embedding: unsupported image format")
+	// ErrImageTooLarge is returned when an image exceeds the size limit
+	// and cannot be shrunk below it
+	ErrImageTooLarge = errors.New("embedding: image too large")
+)
+
+// supportedImageTypes lists the MIME types the multimodal API accepts inline
+var supportedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+}
+
+// ImageOptions controls how local images are prepared for upload
+type ImageOptions struct {
+	// MaxBytes caps the encoded image size before base64; 0 uses 3 MB.
+	// Decodable images above it are shrunk until they fit.
+	MaxBytes int
+	// MaxDimension downscales images whose longer side exceeds it; 0 disables
+	MaxDimension int
+}
+
+// imageOptions reads the configured limits
+func imageOptions(cfg config.ImageConfig) ImageOptions {
+	return ImageOptions{MaxBytes: cfg.MaxBytes, MaxDimension: cfg.MaxDimension}
+}
+
+// ImageDataURI encodes raw image bytes as a base64 data URI. The MIME type is
+// sniffed from the content. JPEG, PNG and GIF images are downscaled to
+// MaxDimension and re-encoded when larger; if they still exceed MaxBytes
+// they are shrunk step by step, lowering JPEG quality and then the size,
+// until they fit. Other formats are passed through and only checked against
+// MaxBytes.
+func ImageDataURI(data []byte, opts ImageOptions) (string, error) {
+	mime := http.DetectContentType(data)
+	if !supportedImageTypes[mime] {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedImage, mime)
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultImageMaxBytes
+	}
+
+	if opts.MaxDimension > 0 && decodable(mime) {
+		resized, newMime, err := downscale(data, mime, opts.MaxDimension)
+		if err != nil {
+			return "", err
+		}
+		data, mime = resized, newMime
+	}
+	if len(data) > maxBytes && decodable(mime) {
+		smaller, newMime, err := shrink(data, mime, maxBytes)
+		if err != nil {
+			return "", err
+		}
+		data, mime = smaller, newMime
+	}
+
+	if len(data) > maxBytes {
+		return "", fmt.Errorf("%w: %d bytes, limit %d", ErrImageTooLarge, len(data), maxBytes)
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ImageFileDataURI reads path and encodes it like ImageDataURI.
+// Files far above the limit are rejected before being read.
+func ImageFileDataURI(path string, opts ImageOptions) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// Downscaling can shrink a big file, so only apply a generous read cap
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultImageMaxBytes
+	}
+	if info.Size() > int64(maxBytes)*16 {
+		return "", fmt.Errorf("%w: %s is %d bytes", ErrImageTooLarge, path, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return ImageDataURI(data, opts)
+}
+
+// EmbeddingImageBytes embeds an image held in memory, e.g. fetched from a
+// private object store, using the configured ali.image limits
+func EmbeddingImageBytes(ctx context.Context, data []byte) (*openai.CreateEmbeddingResponse, error) {
+	uri, err := ImageDataURI(data, imageOptions(config.Cfg.Ali.Image))
+	if err != nil {
+		return nil, err
+	}
+	return EmbeddingImageWithContext(ctx, uri)
+}
+
+// EmbeddingImageFile embeds an image from local disk
+func EmbeddingImageFile(ctx context.Context, path string) (*openai.CreateEmbeddingResponse, error) {
+	uri, err := ImageFileDataURI(path, imageOptions(config.Cfg.Ali.Image))
+	if err != nil {
+		return nil, err
+	}
+	return EmbeddingImageWithContext(ctx, uri)
+}
+
+// decodable reports formats the standard library can decode and re-encode
+func decodable(mime string) bool {
+	return mime == "image/jpeg" || mime == "image/png" || mime == "image/gif"
+}
+
+// downscale shrinks the image so its longer side fits maxDim. Images that
+// already fit are returned unchanged. PNG keeps its format for transparency,
+// everything else becomes JPEG.
+func downscale(data []byte, mime string, maxDim int) ([]byte, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if cfg.Width <= maxDim && cfg.Height <= maxDim {
+		return data, mime, nil
+	}
+	src, err := decodeImage(data, mime)
+	if err != nil {
+		return nil, "", err
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w >= h {
+		h = max(1, h*maxDim/w)
+		w = maxDim
+	} else {
+		w = max(1, w*maxDim/h)
+		h = maxDim
+	}
+	return encodeImage(boxResize(src, w, h), mime, jpegQuality)
+}
+
+// shrink re-encodes an image over maxBytes until it fits. Each round tries
+// the shrinkQualities as JPEG, or plain PNG for PNG, and the next round cuts
+// both sides to 3/4. It gives up with ErrImageTooLarge once the shorter
+// side would drop below minShrinkDimension.
+func shrink(data []byte, mime string, maxBytes int) ([]byte, string, error) {
+	img, err := decodeImage(data, mime)
+	if err != nil {
+		return nil, "", err
+	}
+	for round := 0; ; round++ {
+		if round > 0 {
+			w, h := img.Bounds().Dx()*3/4, img.Bounds().Dy()*3/4
+			if min(w, h) < minShrinkDimension {
+				return nil, "", fmt.Errorf("%w: cannot shrink below %d bytes", ErrImageTooLarge, maxBytes)
+			}
+			img = boxResize(img, w, h)
+		}
+
+		qualities := shrinkQualities
+		if mime == "image/png" {
+			// PNG has no quality knob and the first round is the input itself
+			if round == 0 {
+				continue
+			}
+			qualities = []int{0}
+		}
+		for _, q := range qualities {
+			out, outMime, err := encodeImage(img, mime, q)
+			if err != nil {
+				return nil, "", err
+			}
+			if len(out) <= maxBytes {
+				return out, outMime, nil
+			}
+		}
+	}
+}
+
+// decodeImage decodes a decodable image, refusing ones above
+// maxDecodePixels
+func decodeImage(data []byte, mime string) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if cfg.Width*cfg.Height > maxDecodePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	var img image.Image
+	switch mime {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	return img, nil
+}
+
+// encodeImage writes PNG for PNG and JPEG at quality for everything else
+func encodeImage(img image.Image, mime string, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var err error
+	if mime == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		mime = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("re-encode image: %w", err)
+	}
+	return buf.Bytes(), mime, nil
+}
+
+// boxResize downsamples src to w x h by averaging every source pixel that
+// falls into each destination pixel
+func boxResize(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	}
+	sw, sh := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0 := y * sh / h
+		y1 := max(y0+1, (y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := x * sw / w
+			x1 := max(x0+1, (x+1)*sw/w)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			o := dst.PixOffset(x, y)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
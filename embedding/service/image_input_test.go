@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sea/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makePNG 生成指定尺寸的纯色PNG
+func makePNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// makeNoise 生成难以压缩的随机噪点图
+func makeNoise(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.UintN(256))
+	}
+	return img
+}
+
+// decodeDataURI 拆出data URI的MIME和图片
+func decodeDataURI(t *testing.T, uri string) (string, image.Image) {
+	mime, raw := decodeDataURIBytes(t, uri)
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	require.NoError(t, err)
+	return mime, img
+}
+
+// decodeDataURIBytes 拆出data URI的MIME和原始字节
+func decodeDataURIBytes(t *testing.T, uri string) (string, []byte) {
+	require.True(t, strings.HasPrefix(uri, "data:"))
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	require.True(t, ok)
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	return strings.TrimSuffix(meta, ";base64"), raw
+}
+
+// TestImageDataURIPassThrough 测试小图直接编码且MIME嗅探正确
+func TestImageDataURIPassThrough(t *testing.T) {
+	data := makePNG(t, 10, 20)
+	uri, err := ImageDataURI(data, ImageOptions{MaxDimension: 100})
+	require.NoError(t, err)
+
+	mime, img := decodeDataURI(t, uri)
+	assert.Equal(t, "image/png", mime)
+	assert.Equal(t, image.Rect(0, 0, 10, 20), img.Bounds())
+	assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data), uri)
+}
+
+// TestImageDataURIDownscale 测试超过最大边长时等比缩小
+func TestImageDataURIDownscale(t *testing.T) {
+	data := makePNG(t, 400, 100)
+	uri, err := ImageDataURI(data, ImageOptions{MaxDimension: 100})
+	require.NoError(t, err)
+
+	mime, img := decodeDataURI(t, uri)
+	assert.Equal(t, "image/png", mime)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	assert.Equal(t, 25, img.Bounds().Dy())
+	r, g, b, _ := img.At(5, 5).RGBA()
+	assert.Equal(t, []uint32{200, 100, 50}, []uint32{r >> 8, g >> 8, b >> 8})
+}
+
+// TestImageDataURIShrinksToMaxBytes 测试尺寸合规但字节超限时逐步压缩到限制以内
+func TestImageDataURIShrinksToMaxBytes(t *testing.T) {
+	var jpg bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, makeNoise(300, 200), &jpeg.Options{Quality: 100}))
+	var pngBuf bytes.Buffer
+	require.NoError(t, png.Encode(&pngBuf, makeNoise(300, 200)))
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		wantMime string
+	}{
+		{"jpeg", jpg.Bytes(), "image/jpeg"},
+		{"png", pngBuf.Bytes(), "image/png"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limit := len(tc.data) / 3
+			uri, err := ImageDataURI(tc.data, ImageOptions{MaxBytes: limit, MaxDimension: 1000})
+			require.NoError(t, err)
+			mime, raw := decodeDataURIBytes(t, uri)
+			assert.Equal(t, tc.wantMime, mime)
+			assert.LessOrEqual(t, len(raw), limit)
+			img, _, err := image.Decode(bytes.NewReader(raw))
+			require.NoError(t, err)
+			// 宽高比保持不变
+			assert.InDelta(t, 1.5, float64(img.Bounds().Dx())/float64(img.Bounds().Dy()), 0.05)
+		})
+	}
+}
+
+// TestImageDataURILimits 测试格式和大小限制
+func TestImageDataURILimits(t *testing.T) {
+	_, err := ImageDataURI([]byte("plain text, not an image"), ImageOptions{})
+	assert.True(t, errors.Is(err, ErrUnsupportedImage))
+
+	_, err = ImageDataURI(makePNG(t, 64, 64), ImageOptions{MaxBytes: 10})
+	assert.True(t, errors.Is(err, ErrImageTooLarge))
+}
+
+// TestEmbeddingImageFile 测试本地文件经data URI走配置的后端
+func TestEmbeddingImageFile(t *testing.T) {
+	setupTestConfig(t)
+	config.Cfg.Embedding.Provider = ProviderFake
+	t.Cleanup(func() { config.Cfg.Embedding.Provider = "" })
+
+	path := filepath.Join(t.TempDir(), "cover.png")
+	data := makePNG(t, 8, 8)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	fromFile, err := EmbeddingImageFile(context.Background(), path)
+	require.NoError(t, err)
+	fromBytes, err := EmbeddingImageBytes(context.Background(), data)
+	require.NoError(t, err)
+	assert.Equal(t, fromBytes.Data[0].Embedding, fromFile.Data[0].Embedding)
+
+	_, err = EmbeddingImageFile(context.Background(), filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sea/config"
+	"sea/zlog"
+
+	"github.com/openai/openai-go/v3"
+	"go.uber.org/zap"
+)
+
+// OpenAIEmbedder targets any endpoint speaking the OpenAI embeddings API
+// (OpenAI itself, vLLM, Ollama, ...). It only handles text.
+type OpenAIEmbedder struct {
+	cfg    config.OpenAIConfig
+	client openai.Client
+}
+
+// NewOpenAIEmbedder creates an OpenAI-compatible embedder
+func NewOpenAIEmbedder(cfg config.OpenAIConfig) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		cfg:    cfg,
+		client: newTextClient(cfg.APIKey, cfg.BaseURL),
+	}
+}
+
+// Dimensions reports the configured output dimension
+func (e *OpenAIEmbedder) Dimensions() int {
+	return e.cfg.Dimensions
+}
+
+// EmbedText embeds a single text
+func (e *OpenAIEmbedder) EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error) {
+	return e.embed(ctx, openai.EmbeddingNewParamsInputUnion{
+		OfString: openai.String(text),
+	})
+}
+
+// EmbedTexts embeds several texts in one request
+func (e *OpenAIEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	return e.embed(ctx, openai.EmbeddingNewParamsInputUnion{
+		OfArrayOfStrings: texts,
+	})
+}
+
+// EmbedImage is not part of the OpenAI embeddings API
+func (e *OpenAIEmbedder) EmbedImage(context.Context, string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, fmt.Errorf("openai image embedding: %w", ErrUnsupported)
+}
+
+// EmbedMultiImages is not part of the OpenAI embeddings API
+func (e *OpenAIEmbedder) EmbedMultiImages(context.Context, []string) (*openai.CreateEmbeddingResponse, error) {
+	return nil, fmt.Errorf("openai multi image embedding: %w", ErrUnsupported)
+}
+
+// EmbedMultimodal accepts text items only, embedded as one batch
+func (e *OpenAIEmbedder) EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		text, ok := item.(TextContent)
+		if !ok {
+			return nil, fmt.Errorf("openai %s embedding: %w", item.contentKind(), ErrUnsupported)
+		}
+		texts[i] = text.Text
+	}
+	return e.EmbedTexts(ctx, texts)
+}
+
+func (e *OpenAIEmbedder) embed(ctx context.Context, input openai.EmbeddingNewParamsInputUnion) (*openai.CreateEmbeddingResponse, error) {
+	ctx, cancel := withCallTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	params := openai.EmbeddingNewParams{
+		Input:          input,
+		Model:          e.cfg.Model,
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	}
+	// Not every compatible server accepts dimensions, only send it when set
+	if e.cfg.Dimensions > 0 {
+		params.Dimensions = openai.Int(int64(e.cfg.Dimensions))
+	}
+	res, err := e.client.Embeddings.New(ctx, params)
+	if err != nil {
+		err = fromOpenAIError("openai", err)
+		zlog.L().Error("openai embedding service fail", zap.Error(err))
+		return nil, fmt.Errorf("openai embedding service fail: %w", err)
+	}
+	return res, nil
+}
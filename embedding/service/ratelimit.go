@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sea/config"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go/v3"
+	"golang.org/x/time/rate"
+)
+
+// ErrLocalRateLimited is returned when a call would exceed the configured
+// request or token budget and the caller cannot wait for it
+var ErrLocalRateLimited = errors.New("embedding: local rate limit exceeded")
+
+// RateLimiter holds one request and one token bucket per model. It is safe
+// for concurrent use and meant to be shared by every embedder in the process.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limits   map[string]config.RateLimitConfig
+	limiters map[string]*modelLimiter
+}
+
+type modelLimiter struct {
+	requests *rate.Limiter // nil when unlimited
+	tokens   *rate.Limiter // nil when unlimited
+	failFast bool
+}
+
+// NewRateLimiter creates a limiter from per-model limits.
+// Models missing from limits are not throttled.
+func NewRateLimiter(limits map[string]config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		limits:   limits,
+		limiters: make(map[string]*modelLimiter),
+	}
+}
+
+func (r *RateLimiter) forModel(model string) *modelLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.limiters[model]; ok {
+		return l
+	}
+	cfg, ok := r.limits[model]
+	if !ok {
+		r.limiters[model] = nil
+		return nil
+	}
+	l := &modelLimiter{failFast: cfg.FailFast}
+	if cfg.RequestsPerSecond > 0 {
+		burst := int(math.Ceil(cfg.RequestsPerSecond))
+		l.requests = rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), burst)
+	}
+	if cfg.TokensPerMinute > 0 {
+		l.tokens = rate.NewLimiter(rate.Limit(float64(cfg.TokensPerMinute)/60), cfg.TokensPerMinute)
+	}
+	r.limiters[model] = l
+	return l
+}
+
+// Acquire blocks until model may send a request estimated at tokens.
+// It fails fast when the model is configured so, or when ctx would expire
+// before the budget frees up.
+func (r *RateLimiter) Acquire(ctx context.Context, model string, tokens int) error {
+	l := r.forModel(model)
+	if l == nil {
+		return nil
+	}
+	if l.tokens != nil {
+		tokens = min(tokens, l.tokens.Burst())
+	}
+	if l.failFast {
+		return l.tryAcquire(model, tokens)
+	}
+	return l.wait(ctx, model, tokens)
+}
+
+// wait reserves the request and the tokens together and sleeps until both
+// are due. Whatever stops the wait cancels both reservations, so a caller
+// that gives up does not keep budget it never used.
+func (l *modelLimiter) wait(ctx context.Context, model string, tokens int) error {
+	now := time.Now()
+	var reservations []*rate.Reservation
+	// Cancel as of now: a reservation that was due at once can no longer be
+	// cancelled at a later time
+	cancel := func() {
+		for _, res := range reservations {
+			res.CancelAt(now)
+		}
+	}
+	var delay time.Duration
+	for _, b := range []struct {
+		limiter *rate.Limiter
+		n       int
+		name    string
+	}{{l.requests, 1, "requests"}, {l.tokens, max(tokens, 1), "tokens"}} {
+		if b.limiter == nil {
+			continue
+		}
+		res := b.limiter.ReserveN(now, b.n)
+		if !res.OK() {
+			cancel()
+			return fmt.Errorf("%w: %s %s", ErrLocalRateLimited, model, b.name)
+		}
+		reservations = append(reservations, res)
+		delay = max(delay, res.DelayFrom(now))
+	}
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		cancel()
+		return fmt.Errorf("%w: %s: wait of %s exceeds the deadline", ErrLocalRateLimited, model, delay)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		cancel()
+		return fmt.Errorf("%w: %s: %w", ErrLocalRateLimited, model, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *modelLimiter) tryAcquire(model string, tokens int) error {
+	now := time.Now()
+	var reqRes *rate.Reservation
+	if l.requests != nil {
+		reqRes = l.requests.ReserveN(now, 1)
+		if !reqRes.OK() || reqRes.DelayFrom(now) > 0 {
+			reqRes.CancelAt(now)
+			return fmt.Errorf("%w: %s requests", ErrLocalRateLimited, model)
+		}
+	}
+	if l.tokens != nil && !l.tokens.AllowN(now, max(tokens, 1)) {
+		if reqRes != nil {
+			reqRes.CancelAt(now)
+		}
+		return fmt.Errorf("%w: %s tokens", ErrLocalRateLimited, model)
+	}
+	return nil
+}
+
+// Record charges the tokens a finished call used beyond its estimate.
+// The overspend becomes debt that later Acquire calls wait out.
+func (r *RateLimiter) Record(model string, estimated, used int) {
+	l := r.forModel(model)
+	if l == nil || l.tokens == nil {
+		return
+	}
+	extra := used - max(estimated, 1)
+	if extra <= 0 {
+		return
+	}
+	l.tokens.ReserveN(time.Now(), min(extra, l.tokens.Burst()))
+}
+
+// RateLimitedEmbedder throttles an Embedder through a shared RateLimiter,
+// keyed by the model each modality uses
+type RateLimitedEmbedder struct {
+	inner   Embedder
+	limiter *RateLimiter
+}
+
+// NewRateLimitedEmbedder wraps inner with limiter
+func NewRateLimitedEmbedder(inner Embedder, limiter *RateLimiter) *RateLimitedEmbedder {
+	return &RateLimitedEmbedder{inner: inner, limiter: limiter}
+}
+
+// Dimensions reports the wrapped embedder's dimension
+func (e *RateLimitedEmbedder) Dimensions() int {
+	return e.inner.Dimensions()
+}
+
+// MaxBatchSize forwards the wrapped embedder's batch limit
+func (e *RateLimitedEmbedder) MaxBatchSize() int {
+	if s, ok := e.inner.(batchSizer); ok {
+		return s.MaxBatchSize()
+	}
+	return 0
+}
+
+// TextModel forwards the wrapped embedder's text model
+func (e *RateLimitedEmbedder) TextModel() string {
+	if m, ok := e.inner.(modelReporter); ok {
+		return m.TextModel()
+	}
+	return ""
+}
+
+// MultimodalModel forwards the wrapped embedder's multimodal model
+func (e *RateLimitedEmbedder) MultimodalModel() string {
+	if m, ok := e.inner.(modelReporter); ok {
+		return m.MultimodalModel()
+	}
+	return ""
+}
+
+// EmbedText embeds a single text within the text model's budget
+func (e *RateLimitedEmbedder) EmbedText(ctx context.Context, text string) (*openai.CreateEmbeddingResponse, error) {
+	return e.call(ctx, e.TextModel(), estimateTokens(text), func(ctx context.Context) (*openai.CreateEmbeddingResponse, error) {
+		return e.inner.EmbedText(ctx, text)
+	})
+}
+
+// EmbedTexts embeds several texts within the text model's budget
+func (e *RateLimitedEmbedder) EmbedTexts(ctx context.Context, texts []string) (*openai.CreateEmbeddingResponse, error) {
+	return e.call(ctx, e.TextModel(), estimateTokens(texts...), func(ctx context.Context) (*openai.CreateEmbeddingResponse, error) {
+		return e.inner.EmbedTexts(ctx, texts)
+	})
+}
+
+// EmbedImage embeds an image within the multimodal model's budget.
+// Image token cost is unknown up front and charged from Usage afterwards.
+func (e *RateLimitedEmbedder) EmbedImage(ctx context.Context, imageURL string) (*openai.CreateEmbeddingResponse, error) {
+	return e.call(ctx, e.MultimodalModel(), 0, func(ctx context.Context) (*openai.CreateEmbeddingResponse, error) {
+		return e.inner.EmbedImage(ctx, imageURL)
+	})
+}
+
+// EmbedMultiImages embeds images within the multimodal model's budget
+func (e *RateLimitedEmbedder) EmbedMultiImages(ctx context.Context, imageURLs []string) (*openai.CreateEmbeddingResponse, error) {
+	return e.call(ctx, e.MultimodalModel(), 0, func(ctx context.Context) (*openai.CreateEmbeddingResponse, error) {
+		return e.inner.EmbedMultiImages(ctx, imageURLs)
+	})
+}
+
+// EmbedMultimodal embeds mixed items within the multimodal model's budget.
+// Only text items are estimated up front.
+func (e *RateLimitedEmbedder) EmbedMultimodal(ctx context.Context, items []ContentItem) (*openai.CreateEmbeddingResponse, error) {
+	estimate := 0
+	for _, item := range items {
+		if text, ok := item.(TextContent); ok {
+			estimate += estimateTokens(text.Text)
+		}
+	}
+	return e.call(ctx, e.MultimodalModel(), estimate, func(ctx context.Context) (*openai.CreateEmbeddingResponse, error) {
+		return e.inner.EmbedMultimodal(ctx, items)
+	})
+}
+
+// call waits for the budget, then runs fn with a retry gate in its ctx so
+// retries inside the provider also wait for the request budget
+func (e *RateLimitedEmbedder) call(ctx context.Context, model string, estimate int, fn func(ctx context.Context) (*openai.CreateEmbeddingResponse, error)) (*openai.CreateEmbeddingResponse, error) {
+	if err := e.limiter.Acquire(ctx, model, estimate); err != nil {
+		return nil, err
+	}
+	ctx = withRetryGate(ctx, func(ctx context.Context) error {
+		return e.limiter.Acquire(ctx, model, 0)
+	})
+	res, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.limiter.Record(model, estimate, int(res.Usage.TotalTokens))
+	return res, nil
+}
+
+type retryGateKey struct{}
+
+// withRetryGate attaches gate to ctx; providers call it before every retry
+func withRetryGate(ctx context.Context, gate func(ctx context.Context) error) context.Context {
+	return context.WithValue(ctx, retryGateKey{}, gate)
+}
+
+// passRetryGate runs the gate attached to ctx, if any
+func passRetryGate(ctx context.Context) error {
+	if gate, ok := ctx.Value(retryGateKey{}).(func(ctx context.Context) error); ok {
+		return gate(ctx)
+	}
+	return nil
+}
+
+// estimateTokens approximates token usage before the call. DashScope counts
+// roughly one token per CJK character, so rune count is a safe upper bound.
+func estimateTokens(texts ...string) int {
+	n := 0
+	for _, t := range texts {
+		n += utf8.RuneCountInString(t)
+	}
+	return n
+}
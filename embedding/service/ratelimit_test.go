@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sea/config"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRateLimiterFailFast 测试快速失败模式下超出QPS立即返回错误
+func TestRateLimiterFailFast(t *testing.T) {
+	e := NewRateLimitedEmbedder(NewFakeEmbedder(8), NewRateLimiter(map[string]config.RateLimitConfig{
+		fakeModel: {RequestsPerSecond: 1, FailFast: true},
+	}))
+	ctx := context.Background()
+
+	_, err := e.EmbedText(ctx, "a")
+	require.NoError(t, err)
+	_, err = e.EmbedText(ctx, "b")
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+}
+
+// TestRateLimiterRespectsCallerDeadline 测试阻塞模式下等待超过截止时间时直接失败
+func TestRateLimiterRespectsCallerDeadline(t *testing.T) {
+	e := NewRateLimitedEmbedder(NewFakeEmbedder(8), NewRateLimiter(map[string]config.RateLimitConfig{
+		fakeModel: {RequestsPerSecond: 0.1},
+	}))
+
+	_, err := e.EmbedText(context.Background(), "a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = e.EmbedText(ctx, "b")
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+	assert.Less(t, time.Since(start), 40*time.Millisecond, "不应空等到截止时间")
+}
+
+// TestRateLimiterTokenDebt 测试按实际Usage记账后后续调用需要等待
+func TestRateLimiterTokenDebt(t *testing.T) {
+	limiter := NewRateLimiter(map[string]config.RateLimitConfig{
+		"m": {TokensPerMinute: 600, FailFast: true},
+	})
+
+	require.NoError(t, limiter.Acquire(context.Background(), "m", 10))
+	// 实际消耗远超预估，剩余预算被打成负数
+	limiter.Record("m", 10, 600)
+	err := limiter.Acquire(context.Background(), "m", 10)
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+}
+
+// TestRateLimiterCancelReturnsBudget 测试等待被取消时请求和token预算都退回
+func TestRateLimiterCancelReturnsBudget(t *testing.T) {
+	limiter := NewRateLimiter(map[string]config.RateLimitConfig{
+		"m": {RequestsPerSecond: 10, TokensPerMinute: 60},
+	})
+	// 请求预算还有，token预算用完
+	require.NoError(t, limiter.Acquire(context.Background(), "m", 60))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := limiter.Acquire(ctx, "m", 60)
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	l := limiter.forModel("m")
+	assert.Greater(t, l.requests.Tokens(), 8.5, "请求预算应退回")
+	assert.Greater(t, l.tokens.Tokens(), -1.0, "token预算应退回")
+}
+
+// TestRateLimiterGatesProviderRetries 测试供应商内部的重试也受限流约束
+func TestRateLimiterGatesProviderRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	inner := newRetryTestEmbedder(server.URL, 3)
+	e := NewRateLimitedEmbedder(inner, NewRateLimiter(map[string]config.RateLimitConfig{
+		inner.MultimodalModel(): {RequestsPerSecond: 1, FailFast: true},
+	}))
+	_, err := e.EmbedImage(context.Background(), "https://example.com/a.jpg")
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+	assert.Equal(t, int32(1), calls.Load(), "重试需要先拿到请求预算")
+}
+
+// TestRateLimiterGatesSDKRetries 测试文本路径SDK自带的重试也受限流约束
+func TestRateLimiterGatesSDKRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After-Ms", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	inner := NewDashScopeEmbedder(config.AliConfig{
+		APIKey:     "k",
+		BaseURL:    server.URL,
+		TextModel:  "text-embedding-v4",
+		Dimensions: 4,
+	})
+	e := NewRateLimitedEmbedder(inner, NewRateLimiter(map[string]config.RateLimitConfig{
+		"text-embedding-v4": {RequestsPerSecond: 1, FailFast: true},
+	}))
+	_, err := e.EmbedText(context.Background(), "hello")
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestRateLimiterUnknownModel 测试未配置的模型不限流
+func TestRateLimiterUnknownModel(t *testing.T) {
+	limiter := NewRateLimiter(map[string]config.RateLimitConfig{
+		"m": {RequestsPerSecond: 1, FailFast: true},
+	})
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, limiter.Acquire(context.Background(), "other", 100))
+	}
+}
+
+// TestInitWrapsCacheAndLimiter 测试Init按配置组装缓存和限流
+func TestInitWrapsCacheAndLimiter(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Embedding.Provider = ProviderFake
+	cfg.Embedding.Fake.Dimensions = 8
+	cfg.Embedding.Cache.Enabled = true
+	cfg.Embedding.RateLimits = map[string]config.RateLimitConfig{
+		fakeModel: {RequestsPerSecond: 1, FailFast: true},
+	}
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Close() })
+
+	ctx := context.Background()
+	_, err := EmbeddingTxtWithContext(ctx, "same")
+	require.NoError(t, err)
+	// 第二次命中缓存，不消耗限流配额
+	_, err = EmbeddingTxtWithContext(ctx, "same")
+	require.NoError(t, err)
+	_, err = EmbeddingTxtWithContext(ctx, "different")
+	assert.True(t, errors.Is(err, ErrLocalRateLimited))
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sea/config"
+	"strconv"
+	"time"
+)
+
+// RetryError reports a request that failed after Attempts tries.
+// Err is the failure of the last attempt.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retryPolicy is the resolved form of config.RetryConfig
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+	}
+	if p.maxAttempts < 1 {
+		p.maxAttempts = 1
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = 200 * time.Millisecond
+	}
+	if p.maxBackoff < p.initialBackoff {
+		p.maxBackoff = p.initialBackoff
+	}
+	return p
+}
+
+// backoff returns a full-jitter delay before retry number attempt (1-based)
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.initialBackoff << min(attempt-1, 30)
+	if ceiling <= 0 || ceiling > p.maxBackoff {
+		ceiling = p.maxBackoff
+	}
+	return rand.N(ceiling) + 1
+}
+
+// attemptError is the outcome of a single HTTP attempt
+type attemptError struct {
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+}
+
+// retryableStatus reports transient HTTP statuses worth another attempt.
+// Embedding requests have no side effects, so replaying them is safe.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableTransportError reports network failures that are worth retrying.
+// Cancellation by the caller is never retried.
+func retryableTransportError(parent context.Context, err error) bool {
+	if parent.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// parseRetryAfter understands both delta-seconds and HTTP-date forms
+func parseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// withRetry runs fn until it succeeds, fails permanently or attempts run out.
+// Server supplied Retry-After wins over the computed backoff but is capped
+// at maxBackoff, so one huge value cannot stall the caller. When ctx would
+// expire before the next attempt could start, it gives up immediately.
+// Every retry also passes the rate limit gate in ctx, if any.
+func withRetry[T any](ctx context.Context, p retryPolicy, fn func(ctx context.Context) (T, *attemptError)) (T, error) {
+	var zero T
+	for attempt := 1; ; attempt++ {
+		res, aerr := fn(ctx)
+		if aerr == nil {
+			return res, nil
+		}
+		if !aerr.retryable || attempt >= p.maxAttempts {
+			return zero, &RetryError{Attempts: attempt, Err: aerr.err}
+		}
+
+		wait := p.backoff(attempt)
+		if aerr.retryAfter > 0 {
+			wait = min(aerr.retryAfter, p.maxBackoff)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return zero, &RetryError{Attempts: attempt, Err: aerr.err}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, &RetryError{Attempts: attempt, Err: errors.Join(aerr.err, ctx.Err())}
+		case <-timer.C:
+		}
+		if err := passRetryGate(ctx); err != nil {
+			return zero, &RetryError{Attempts: attempt, Err: errors.Join(aerr.err, err)}
+		}
+	}
+}
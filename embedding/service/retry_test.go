@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sea/config"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRetryTestEmbedder 创建指向测试服务器、重试间隔极短的DashScope实现
+func newRetryTestEmbedder(url string, attempts int) *DashScopeEmbedder {
+	return NewDashScopeEmbedder(config.AliConfig{
+		MultimodalBaseURL: url,
+		MultimodalModel:   "qwen2.5-vl-embedding",
+		Dimensions:        4,
+		Retry: config.RetryConfig{
+			MaxAttempts:    attempts,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+		},
+	})
+}
+
+const okMultimodalBody = `{"output":{"embeddings":[{"index":0,"embedding":[0.1,0.2,0.3,0.4]}]},"usage":{"total_tokens":3}}`
+
+// TestMultimodalRetryThenSuccess 测试429后按Retry-After重试成功
+func TestMultimodalRetryThenSuccess(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(okMultimodalBody))
+	}))
+	defer server.Close()
+
+	res, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "https://example.com/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Len(t, res.Data[0].Embedding, 4)
+}
+
+// TestMultimodalRetryExhausted 测试持续5xx时报告尝试次数
+func TestMultimodalRetryExhausted(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "https://example.com/a.jpg")
+	require.Error(t, err)
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// TestMultimodalNoRetryOnClientError 测试4xx请求错误不重试
+func TestMultimodalNoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "https://example.com/a.jpg")
+	require.Error(t, err)
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 1, retryErr.Attempts)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestMultimodalRetryAfterBeyondDeadline 测试Retry-After超过截止时间时立即放弃
+func TestMultimodalRetryAfterBeyondDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(ctx, "https://example.com/a.jpg")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+// TestMultimodalRetryAfterCapped 测试过大的Retry-After按MaxBackoff封顶
+func TestMultimodalRetryAfterCapped(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(okMultimodalBody))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "https://example.com/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestMultimodalNoRetryOnQuota 测试额度用尽的429不重试
+func TestMultimodalNoRetryOnQuota(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":"Arrearage","message":"account in arrears","request_id":"r-q"}`))
+	}))
+	defer server.Close()
+
+	_, err := newRetryTestEmbedder(server.URL, 3).EmbedImage(context.Background(), "https://example.com/a.jpg")
+	var quota *QuotaError
+	require.ErrorAs(t, err, &quota)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestParseRetryAfter 测试Retry-After两种格式的解析
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h := http.Header{}
+	h.Set("Retry-After", "7")
+	d, ok := parseRetryAfter(h, now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	h.Set("Retry-After", now.Add(3*time.Second).Format(http.TimeFormat))
+	d, ok = parseRetryAfter(h, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	h.Set("Retry-After", "soon")
+	_, ok = parseRetryAfter(h, now)
+	assert.False(t, ok)
+}
+
+// TestRetryBackoffBounds 测试退避时间不超过上限
+func TestRetryBackoffBounds(t *testing.T) {
+	p := newRetryPolicy(config.RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     40 * time.Millisecond,
+	})
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := p.backoff(attempt)
+		assert.Greater(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, 40*time.Millisecond+1)
+	}
+}
@@ -0,0 +1,117 @@
+package similarity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	schema "sea/embedding/schema/vector"
+	"sea/recall"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+var vectorFields = []string{schema.FieldID, schema.FieldVector, schema.FieldArticleID, schema.FieldNodeID}
+
+// MilvusVectors reads vectors from the precise recall collection. Reads are
+// strongly consistent so chunks upserted just before are visible.
+type MilvusVectors struct {
+	client     *milvusclient.Client
+	collection string
+}
+
+func NewMilvusVectors(client *milvusclient.Client) *MilvusVectors {
+	return &MilvusVectors{client: client, collection: schema.RecallPreciseCollection}
+}
+
+func (m *MilvusVectors) Vectors(ctx context.Context, ids []string) ([]Vector, error) {
+	if m.client == nil {
+		return nil, errors.New("similarity: milvus client not initialized")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rs, err := m.client.Query(ctx, milvusclient.NewQueryOption(m.collection).
+		WithFilter(fmt.Sprintf("%s in %s", schema.FieldID, recall.StringList(ids))).
+		WithOutputFields(vectorFields...).
+		WithConsistencyLevel(entity.ClStrong))
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", m.collection, err)
+	}
+	return vectorsFromResult(rs)
+}
+
+func (m *MilvusVectors) Scan(ctx context.Context, batchSize int, fn func([]Vector) error) error {
+	if m.client == nil {
+		return errors.New("similarity: milvus client not initialized")
+	}
+	it, err := m.client.QueryIterator(ctx, milvusclient.NewQueryIteratorOption(m.collection).
+		WithFilter(fmt.Sprintf("%s != \"\"", schema.FieldID)).
+		WithOutputFields(vectorFields...).
+		WithBatchSize(batchSize))
+	if err != nil {
+		return fmt.Errorf("scan %s: %w", m.collection, err)
+	}
+	for {
+		rs, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", m.collection, err)
+		}
+		vecs, err := vectorsFromResult(rs)
+		if err != nil {
+			return err
+		}
+		if err := fn(vecs); err != nil {
+			return err
+		}
+	}
+}
+
+func vectorsFromResult(rs milvusclient.ResultSet) ([]Vector, error) {
+	if rs.Err != nil {
+		return nil, rs.Err
+	}
+	ids, vectors := rs.GetColumn(schema.FieldID), rs.GetColumn(schema.FieldVector)
+	if ids == nil || vectors == nil {
+		return nil, fmt.Errorf("similarity: result misses %s or %s", schema.FieldID, schema.FieldVector)
+	}
+	articles, nodes := rs.GetColumn(schema.FieldArticleID), rs.GetColumn(schema.FieldNodeID)
+
+	out := make([]Vector, 0, rs.ResultCount)
+	for i := 0; i < rs.ResultCount; i++ {
+		id, err := ids.GetAsString(i)
+		if err != nil {
+			return nil, fmt.Errorf("read id: %w", err)
+		}
+		raw, err := vectors.Get(i)
+		if err != nil {
+			return nil, fmt.Errorf("read vector of %s: %w", id, err)
+		}
+		vec, ok := raw.(entity.FloatVector)
+		if !ok {
+			return nil, fmt.Errorf("similarity: vector of %s is %T", id, raw)
+		}
+		v := Vector{ID: id, NodeID: id, ArticleID: stringAt(articles, i), Vector: vec}
+		if node := stringAt(nodes, i); node != "" {
+			v.NodeID = node
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func stringAt(col column.Column, i int) string {
+	if col == nil {
+		return ""
+	}
+	s, err := col.GetAsString(i)
+	if err != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+// Package similarity links chunks whose vectors are close in the precise
+// recall collection with SIMILAR_TO edges.
+package similarity
+
+import (
+	"context"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/recall"
+	"sort"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// Similarity edges are stored once per pair, from the smaller node id to the
+// larger one; graph expansion follows them in both directions
+const (
+	RelSimilarTo = "SIMILAR_TO"
+	EdgeTag      = "similar"
+
+	defaultTopK     = 10
+	defaultMinScore = 0.8
+	defaultBatch    = 256
+)
+
+// Vector is one stored chunk vector
+type Vector struct {
+	ID        string
+	NodeID    string
+	ArticleID string
+	Vector    []float32
+}
+
+// VectorSource reads stored vectors back from the vector store
+type VectorSource interface {
+	// Vectors returns the vectors of ids that exist, in no particular order
+	Vectors(ctx context.Context, ids []string) ([]Vector, error)
+	// Scan calls fn with every stored vector in batches of up to batchSize
+	Scan(ctx context.Context, batchSize int, fn func([]Vector) error) error
+}
+
+// EdgeStore is the part of graph.Repository used by the job
+type EdgeStore interface {
+	UpsertEdges(ctx context.Context, edges []graph.Edge) error
+	DeleteEdges(ctx context.Context, nodeIDs []string, relType string) (int64, error)
+	DeleteEdgesOfType(ctx context.Context, relType string) (int64, error)
+}
+
+type Options struct {
+	// TopK neighbors are looked up per vector
+	TopK int
+	// MinScore is the lowest cosine similarity that becomes an edge
+	MinScore float64
+	// Metric of the precise collection, used to turn scores into cosine
+	Metric entity.MetricType
+}
+
+// Job writes SIMILAR_TO edges between each chunk and its nearest chunks of
+// other articles; chunks of the same article are already linked through
+// their parents. Weight is the cosine similarity.
+type Job struct {
+	vectors  VectorSource
+	searcher recall.Searcher
+	edges    EdgeStore
+	opts     Options
+}
+
+func NewJob(vectors VectorSource, searcher recall.Searcher, edges EdgeStore, opts Options) *Job {
+	if opts.TopK <= 0 {
+		opts.TopK = defaultTopK
+	}
+	if opts.MinScore <= 0 {
+		opts.MinScore = defaultMinScore
+	}
+	if opts.Metric == "" {
+		opts.Metric = entity.COSINE
+	}
+	return &Job{vectors: vectors, searcher: searcher, edges: edges, opts: opts}
+}
+
+// BuildFor replaces the similarity edges of the given chunks and returns
+// the number of edges written. Call it after new chunks were ingested; the
+// older chunks keep their edges, so nothing is rebuilt as the corpus grows.
+func (j *Job) BuildFor(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	vecs, err := j.vectors.Vectors(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	if len(vecs) == 0 {
+		return 0, nil
+	}
+	edges, err := j.edgesFor(ctx, vecs)
+	if err != nil {
+		return 0, err
+	}
+	nodeIDs := make([]string, len(vecs))
+	for i, v := range vecs {
+		nodeIDs[i] = v.NodeID
+	}
+	if _, err := j.edges.DeleteEdges(ctx, nodeIDs, RelSimilarTo); err != nil {
+		return 0, err
+	}
+	if len(edges) == 0 {
+		return 0, nil
+	}
+	return len(edges), j.edges.UpsertEdges(ctx, edges)
+}
+
+// Rebuild recomputes the similarity edges of every stored vector. All
+// similarity edges are dropped once up front; deleting per batch would also
+// drop the edges earlier batches wrote to nodes of the current one.
+func (j *Job) Rebuild(ctx context.Context) (int, error) {
+	if _, err := j.edges.DeleteEdgesOfType(ctx, RelSimilarTo); err != nil {
+		return 0, err
+	}
+	total := 0
+	err := j.vectors.Scan(ctx, defaultBatch, func(vecs []Vector) error {
+		edges, err := j.edgesFor(ctx, vecs)
+		if err != nil || len(edges) == 0 {
+			return err
+		}
+		if err := j.edges.UpsertEdges(ctx, edges); err != nil {
+			return err
+		}
+		total += len(edges)
+		return nil
+	})
+	return total, err
+}
+
+// edgesFor searches the neighbors of vecs and returns one edge per pair
+// above MinScore, sorted by edge id
+func (j *Job) edgesFor(ctx context.Context, vecs []Vector) ([]graph.Edge, error) {
+	byID := make(map[string]graph.Edge)
+	for _, v := range vecs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hits, err := j.searcher.Search(ctx, recall.SearchRequest{
+			Collection:   schema.RecallPreciseCollection,
+			Vector:       v.Vector,
+			TopK:         j.opts.TopK,
+			Filter:       schema.FieldArticleID + " != " + recall.StringLiteral(v.ArticleID),
+			OutputFields: []string{schema.FieldNodeID},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hits {
+			score := Cosine(j.opts.Metric, h.Score)
+			if score < j.opts.MinScore {
+				continue
+			}
+			e := similarEdge(v.NodeID, hitNodeID(h), score)
+			if old, ok := byID[e.EdgeID]; !ok || e.Weight > old.Weight {
+				byID[e.EdgeID] = e
+			}
+		}
+	}
+	edges := make([]graph.Edge, 0, len(byID))
+	for _, e := range byID {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(a, b int) bool { return edges[a].EdgeID < edges[b].EdgeID })
+	return edges, nil
+}
+
+// Cosine turns a raw score into cosine similarity. Stored vectors are L2
+// normalized, so IP equals cosine and a squared L2 distance d is 1 - d/2.
+func Cosine(metric entity.MetricType, score float32) float64 {
+	if metric == entity.L2 {
+		return 1 - float64(score)/2
+	}
+	return float64(score)
+}
+
+// hitNodeID is the graph node of a hit, its chunk id unless the node_id
+// field says otherwise
+func hitNodeID(h recall.Hit) string {
+	if id, ok := h.Fields[schema.FieldNodeID].(string); ok && id != "" {
+		return id
+	}
+	return h.ID
+}
+
+func similarEdge(a, b string, weight float64) graph.Edge {
+	if b < a {
+		a, b = b, a
+	}
+	return graph.Edge{
+		EdgeID:     "sim:" + a + "|" + b,
+		FromNodeID: a,
+		ToNodeID:   b,
+		Type:       RelSimilarTo,
+		Weight:     weight,
+		Tag:        EdgeTag,
+	}
+}
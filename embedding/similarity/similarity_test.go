@@ -0,0 +1,186 @@
+package similarity
+
+import (
+	"context"
+	"errors"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/recall"
+	"slices"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memVectors 内存版向量源
+type memVectors struct {
+	vectors []Vector
+	batch   int // 覆盖 Scan 的批大小
+}
+
+func (m *memVectors) Vectors(ctx context.Context, ids []string) ([]Vector, error) {
+	var out []Vector
+	for _, v := range m.vectors {
+		for _, id := range ids {
+			if v.ID == id {
+				out = append(out, v)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *memVectors) Scan(ctx context.Context, batchSize int, fn func([]Vector) error) error {
+	if m.batch > 0 {
+		batchSize = m.batch
+	}
+	for start := 0; start < len(m.vectors); start += batchSize {
+		if err := fn(m.vectors[start:min(start+batchSize, len(m.vectors))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stubSearcher 按向量的第一维返回固定结果
+type stubSearcher struct {
+	hits map[float32][]recall.Hit
+	reqs []recall.SearchRequest
+	err  error
+}
+
+func (s *stubSearcher) Search(ctx context.Context, req recall.SearchRequest) ([]recall.Hit, error) {
+	s.reqs = append(s.reqs, req)
+	return s.hits[req.Vector[0]], s.err
+}
+
+// memEdges 内存版边存储
+type memEdges struct {
+	edges   map[string]graph.Edge
+	deleted [][]string
+}
+
+func newMemEdges() *memEdges {
+	return &memEdges{edges: make(map[string]graph.Edge)}
+}
+
+func (m *memEdges) UpsertEdges(ctx context.Context, edges []graph.Edge) error {
+	for _, e := range edges {
+		m.edges[e.EdgeID] = e
+	}
+	return nil
+}
+
+func (m *memEdges) DeleteEdges(ctx context.Context, nodeIDs []string, relType string) (int64, error) {
+	m.deleted = append(m.deleted, nodeIDs)
+	var n int64
+	for id, e := range m.edges {
+		if e.Type == relType && (slices.Contains(nodeIDs, e.FromNodeID) || slices.Contains(nodeIDs, e.ToNodeID)) {
+			delete(m.edges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memEdges) DeleteEdgesOfType(ctx context.Context, relType string) (int64, error) {
+	var n int64
+	for id, e := range m.edges {
+		if e.Type == relType {
+			delete(m.edges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func testVectors() *memVectors {
+	return &memVectors{vectors: []Vector{
+		{ID: "a#c0", NodeID: "a#c0", ArticleID: "a", Vector: []float32{1}},
+		{ID: "a#c1", NodeID: "a#c1", ArticleID: "a", Vector: []float32{2}},
+		{ID: "b#c0", NodeID: "b#c0", ArticleID: "b", Vector: []float32{3}},
+	}}
+}
+
+func TestBuildFor(t *testing.T) {
+	searcher := &stubSearcher{hits: map[float32][]recall.Hit{
+		1: {{ID: "b#c0", Score: 0.95}, {ID: "c#c0", Score: 0.5}},
+		2: {{ID: "b#c0", Score: 0.85, Fields: map[string]any{schema.FieldNodeID: "b#c0"}}},
+	}}
+	edges := newMemEdges()
+	job := NewJob(testVectors(), searcher, edges, Options{TopK: 5, MinScore: 0.8})
+
+	n, err := job.BuildFor(context.Background(), []string{"a#c0", "a#c1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	e, ok := edges.edges["sim:a#c0|b#c0"]
+	require.True(t, ok)
+	assert.Equal(t, RelSimilarTo, e.Type)
+	assert.Equal(t, EdgeTag, e.Tag)
+	assert.InDelta(t, 0.95, e.Weight, 1e-6)
+	assert.Contains(t, edges.edges, "sim:a#c1|b#c0")
+	// 低于阈值的不建边
+	assert.NotContains(t, edges.edges, "sim:a#c0|c#c0")
+	assert.Equal(t, [][]string{{"a#c0", "a#c1"}}, edges.deleted)
+
+	// 只搜别的文章
+	require.Len(t, searcher.reqs, 2)
+	assert.Equal(t, schema.RecallPreciseCollection, searcher.reqs[0].Collection)
+	assert.Equal(t, 5, searcher.reqs[0].TopK)
+	assert.Equal(t, `article_id != "a"`, searcher.reqs[0].Filter)
+}
+
+func TestRebuildKeepsStrongestPair(t *testing.T) {
+	searcher := &stubSearcher{hits: map[float32][]recall.Hit{
+		1: {{ID: "b#c0", Score: 0.9}},
+		3: {{ID: "a#c0", Score: 0.92}},
+	}}
+	edges := newMemEdges()
+	job := NewJob(testVectors(), searcher, edges, Options{})
+
+	n, err := job.Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.InDelta(t, 0.92, edges.edges["sim:a#c0|b#c0"].Weight, 1e-6)
+}
+
+func TestRebuildKeepsEarlierBatches(t *testing.T) {
+	searcher := &stubSearcher{hits: map[float32][]recall.Hit{
+		1: {{ID: "b#c0", Score: 0.9}},
+		3: {{ID: "c#c0", Score: 0.85}},
+	}}
+	edges := newMemEdges()
+	edges.edges["sim:old|stale"] = similarEdge("old", "stale", 0.99)
+	vectors := testVectors()
+	vectors.batch = 1
+	job := NewJob(vectors, searcher, edges, Options{})
+
+	n, err := job.Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	// b#c0 在后面的批次里，前面批次写的 a#c0-b#c0 不能被删掉
+	assert.Contains(t, edges.edges, "sim:a#c0|b#c0")
+	assert.Contains(t, edges.edges, "sim:b#c0|c#c0")
+	assert.NotContains(t, edges.edges, "sim:old|stale")
+	assert.Empty(t, edges.deleted)
+}
+
+func TestBuildForSearchError(t *testing.T) {
+	edges := newMemEdges()
+	job := NewJob(testVectors(), &stubSearcher{err: errors.New("milvus down")}, edges, Options{})
+	_, err := job.BuildFor(context.Background(), []string{"a#c0"})
+	require.Error(t, err)
+	// 搜索失败时保留旧边
+	assert.Empty(t, edges.deleted)
+}
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 0.9, Cosine(entity.COSINE, 0.9), 1e-6)
+	assert.InDelta(t, 0.9, Cosine(entity.IP, 0.9), 1e-6)
+	// 单位向量夹角 90 度时平方距离为 2
+	assert.InDelta(t, 0.0, Cosine(entity.L2, 2), 1e-6)
+	assert.InDelta(t, 1.0, Cosine(entity.L2, 0), 1e-6)
+}
@@ -0,0 +1,137 @@
+package vecutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ToFloat16 encodes v as IEEE 754 half precision, little-endian, the byte
+// layout of a Milvus Float16Vector row. Rounds to nearest even; values
+// beyond ±65504 become ±Inf, so normalize first.
+func ToFloat16(v []float32) []byte {
+	out := make([]byte, len(v)*2)
+	for i, x := range v {
+		binary.LittleEndian.PutUint16(out[i*2:], float32ToFloat16(x))
+	}
+	return out
+}
+
+// FromFloat16 decodes a Float16Vector row back to float32
+func FromFloat16(b []byte) []float32 {
+	out := make([]float32, len(b)/2)
+	for i := range out {
+		out[i] = float16ToFloat32(binary.LittleEndian.Uint16(b[i*2:]))
+	}
+	return out
+}
+
+// ToBFloat16 encodes v as bfloat16, little-endian, the byte layout of a
+// Milvus BFloat16Vector row. Keeps the float32 range with 8 bits of mantissa.
+func ToBFloat16(v []float32) []byte {
+	out := make([]byte, len(v)*2)
+	for i, x := range v {
+		binary.LittleEndian.PutUint16(out[i*2:], float32ToBFloat16(x))
+	}
+	return out
+}
+
+// FromBFloat16 decodes a BFloat16Vector row back to float32
+func FromBFloat16(b []byte) []float32 {
+	out := make([]float32, len(b)/2)
+	for i := range out {
+		out[i] = math.Float32frombits(uint32(binary.LittleEndian.Uint16(b[i*2:])) << 16)
+	}
+	return out
+}
+
+// ToBinary sign-quantizes v into a Milvus BinaryVector row: bit i is set when
+// v[i] > 0, packed most significant bit first. Milvus requires the dimension
+// to be a multiple of 8. Hamming distance on these rows approximates angular
+// distance, which is enough for the coarse recall stage.
+func ToBinary(v []float32) ([]byte, error) {
+	if len(v)%8 != 0 {
+		return nil, fmt.Errorf("%w: binary vector dim %d is not a multiple of 8", ErrInvalidVector, len(v))
+	}
+	out := make([]byte, len(v)/8)
+	for i, x := range v {
+		if x > 0 {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out, nil
+}
+
+func float32ToFloat16(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int32(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if exp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00 // quiet NaN
+		}
+		return sign | 0x7c00
+	}
+
+	e := exp - 127 + 15
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+	if e <= 0 {
+		// Subnormal half: shift the full 24-bit significand into 10 bits
+		if e < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - e)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+
+	half := uint32(e)<<10 | mant>>13
+	rem := mant & 0x1fff
+	// A carry out of the mantissa correctly bumps the exponent, up to Inf
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return sign | uint16(half)
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal: value is mant * 2^-24
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
+
+func float32ToBFloat16(f float32) uint16 {
+	b := math.Float32bits(f)
+	if b&0x7fffffff > 0x7f800000 {
+		return uint16(b>>16) | 0x40 // keep NaN a NaN after truncation
+	}
+	rounding := uint32(0x7fff) + (b>>16)&1
+	return uint16((b + rounding) >> 16)
+}
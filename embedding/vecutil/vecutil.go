@@ -0,0 +1,102 @@
+// Package vecutil prepares embedding vectors for Milvus: float64 -> float32
+// conversion, NaN/Inf validation, L2 normalization for COSINE/IP metrics and
+// quantization to float16, bfloat16 and binary vectors.
+package vecutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// ErrInvalidVector marks vectors that cannot be stored or compared
+var ErrInvalidVector = errors.New("invalid vector")
+
+// Validate rejects empty vectors and vectors containing NaN or ±Inf
+func Validate(v []float64) error {
+	if len(v) == 0 {
+		return fmt.Errorf("%w: empty", ErrInvalidVector)
+	}
+	for i, x := range v {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("%w: element %d is %v", ErrInvalidVector, i, x)
+		}
+	}
+	return nil
+}
+
+// ToFloat32 converts v for Milvus FloatVector columns.
+// Values beyond the float32 range fail instead of turning into ±Inf.
+func ToFloat32(v []float64) ([]float32, error) {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		f := float32(x)
+		if math.IsInf(float64(f), 0) && !math.IsInf(x, 0) {
+			return nil, fmt.Errorf("%w: element %d overflows float32", ErrInvalidVector, i)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+// L2Normalize scales v in place to unit length. The sum is accumulated in
+// float64 to keep precision on 2048-dim vectors. A zero vector has no
+// direction and is reported as invalid.
+func L2Normalize(v []float32) error {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return fmt.Errorf("%w: zero norm", ErrInvalidVector)
+	}
+	inv := 1 / math.Sqrt(sum)
+	for i, x := range v {
+		v[i] = float32(float64(x) * inv)
+	}
+	return nil
+}
+
+// Prepare validates v, converts it to float32 and optionally normalizes it.
+// This is the usual path from an embedding response to a Milvus row.
+func Prepare(v []float64, normalize bool) ([]float32, error) {
+	if err := Validate(v); err != nil {
+		return nil, err
+	}
+	out, err := ToFloat32(v)
+	if err != nil {
+		return nil, err
+	}
+	if normalize {
+		if err := L2Normalize(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// FromResponse prepares every embedding in res, ordered by its Index
+func FromResponse(res *openai.CreateEmbeddingResponse, normalize bool) ([][]float32, error) {
+	if res == nil {
+		return nil, fmt.Errorf("%w: nil response", ErrInvalidVector)
+	}
+	out := make([][]float32, len(res.Data))
+	for _, d := range res.Data {
+		if d.Index < 0 || int(d.Index) >= len(out) {
+			return nil, fmt.Errorf("%w: index %d out of range", ErrInvalidVector, d.Index)
+		}
+		v, err := Prepare(d.Embedding, normalize)
+		if err != nil {
+			return nil, fmt.Errorf("embedding %d: %w", d.Index, err)
+		}
+		out[d.Index] = v
+	}
+	for i, v := range out {
+		if v == nil {
+			return nil, fmt.Errorf("%w: missing embedding %d", ErrInvalidVector, i)
+		}
+	}
+	return out, nil
+}
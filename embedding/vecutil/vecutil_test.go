@@ -0,0 +1,100 @@
+package vecutil
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidate 测试NaN/Inf/空向量校验
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]float64{0.1, -0.2}))
+	assert.True(t, errors.Is(Validate(nil), ErrInvalidVector))
+	assert.True(t, errors.Is(Validate([]float64{1, math.NaN()}), ErrInvalidVector))
+	assert.True(t, errors.Is(Validate([]float64{math.Inf(-1)}), ErrInvalidVector))
+}
+
+// TestToFloat32Overflow 测试超出float32范围时报错
+func TestToFloat32Overflow(t *testing.T) {
+	v, err := ToFloat32([]float64{1.5, -2})
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1.5, -2}, v)
+
+	_, err = ToFloat32([]float64{1e300})
+	assert.True(t, errors.Is(err, ErrInvalidVector))
+}
+
+// TestPrepareNormalizes 测试转换并归一化为单位向量
+func TestPrepareNormalizes(t *testing.T) {
+	v, err := Prepare([]float64{3, 4}, true)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.6, v[0], 1e-7)
+	assert.InDelta(t, 0.8, v[1], 1e-7)
+
+	_, err = Prepare([]float64{0, 0}, true)
+	assert.True(t, errors.Is(err, ErrInvalidVector))
+
+	raw, err := Prepare([]float64{3, 4}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{3, 4}, raw)
+}
+
+// TestFromResponseOrdersByIndex 测试按Index还原响应顺序
+func TestFromResponseOrdersByIndex(t *testing.T) {
+	res := &openai.CreateEmbeddingResponse{
+		Data: []openai.Embedding{
+			{Index: 1, Embedding: []float64{0, 2}},
+			{Index: 0, Embedding: []float64{2, 0}},
+		},
+	}
+	out, err := FromResponse(res, true)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, out)
+
+	res.Data = res.Data[:1]
+	_, err = FromResponse(res, true)
+	assert.Error(t, err)
+}
+
+// TestFloat16RoundTrip 测试半精度编码的精度和特殊值
+func TestFloat16RoundTrip(t *testing.T) {
+	in := []float32{0, 1, -1, 0.5, 0.333333, 65504, 1e-5, 6e-8, -0.0001}
+	out := FromFloat16(ToFloat16(in))
+	require.Len(t, out, len(in))
+	for i := range in {
+		assert.InDelta(t, in[i], out[i], math.Max(math.Abs(float64(in[i]))*1e-3, 6e-8), "index %d", i)
+	}
+
+	// 已知编码
+	assert.Equal(t, uint16(0x3c00), float32ToFloat16(1))
+	assert.Equal(t, uint16(0xc000), float32ToFloat16(-2))
+	assert.Equal(t, uint16(0x7bff), float32ToFloat16(65504))
+	assert.Equal(t, uint16(0x7c00), float32ToFloat16(70000))
+	assert.Equal(t, uint16(0x0001), float32ToFloat16(float32(math.Pow(2, -24))))
+	assert.True(t, math.IsNaN(float64(float16ToFloat32(float32ToFloat16(float32(math.NaN()))))))
+}
+
+// TestBFloat16RoundTrip 测试bfloat16编码
+func TestBFloat16RoundTrip(t *testing.T) {
+	in := []float32{0, 1, -1, 0.1, 3.14159, 1e30, -1e-30}
+	out := FromBFloat16(ToBFloat16(in))
+	for i := range in {
+		assert.InDelta(t, in[i], out[i], math.Abs(float64(in[i]))*1e-2, "index %d", i)
+	}
+	assert.Equal(t, uint16(0x3f80), float32ToBFloat16(1))
+	assert.True(t, math.IsNaN(float64(FromBFloat16(ToBFloat16([]float32{float32(math.NaN())}))[0])))
+}
+
+// TestToBinary 测试符号量化与位序
+func TestToBinary(t *testing.T) {
+	b, err := ToBinary([]float32{1, -1, 0, 2, -3, 4, 5, -6, 0.1, 0, 0, 0, 0, 0, 0, 0.2})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0b10010110, 0b10000001}, b)
+
+	_, err = ToBinary([]float32{1, 2, 3})
+	assert.True(t, errors.Is(err, ErrInvalidVector))
+}
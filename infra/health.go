@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sea/config"
+	schema "sea/embedding/schema/vector"
+	"strings"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+// CheckMilvus verifies the shared client can reach Milvus and that both
+// recall collections are loaded, since searches fail on unloaded ones.
+func CheckMilvus(ctx context.Context) error {
+	client := Milvus()
+	if client == nil {
+		return ErrNotInitialized
+	}
+	for _, name := range []string{schema.RecallCandidateCollection, schema.RecallPreciseCollection} {
+		state, err := client.GetLoadState(ctx, milvusclient.NewGetLoadStateOption(name))
+		if err != nil {
+			return fmt.Errorf("collection %s: %w", name, err)
+		}
+		if state.State != entity.LoadStateLoaded {
+			return fmt.Errorf("collection %s not loaded (state %d, progress %d%%)", name, state.State, state.Progress)
+		}
+	}
+	return nil
+}
+
+// CheckNeo4j verifies the shared driver can reach the server
+func CheckNeo4j(ctx context.Context) error {
+	driver := Neo4j()
+	if driver == nil {
+		return ErrNotInitialized
+	}
+	return driver.VerifyConnectivity(ctx)
+}
+
+// CheckKafka dials every configured broker. A TCP connection is enough to
+// tell the broker is up without pulling in a Kafka client here.
+func CheckKafka(ctx context.Context) error {
+	addrs := strings.Split(config.Cfg.Kafka.Address, ",")
+	var dialer net.Dialer
+	checked := 0
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("broker %s: %w", addr, err)
+		}
+		_ = conn.Close()
+		checked++
+	}
+	if checked == 0 {
+		return fmt.Errorf("no kafka broker configured")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Long-lived clients opened by MilvusInit and Neo4jInit. Both are safe for
+// concurrent use and shared by the whole process; Close releases them.
+var (
+	mu           sync.RWMutex
+	milvusClient *milvusclient.Client
+	neo4jDriver  neo4j.DriverWithContext
+)
+
+// ErrNotInitialized is returned when a client is used before its Init ran
+var ErrNotInitialized = errors.New("infra: client not initialized")
+
+// Milvus returns the shared Milvus client, nil before MilvusInit
+func Milvus() *milvusclient.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return milvusClient
+}
+
+// Neo4j returns the shared Neo4j driver, nil before Neo4jInit.
+// Open a session per unit of work; the driver pools the connections.
+func Neo4j() neo4j.DriverWithContext {
+	mu.RLock()
+	defer mu.RUnlock()
+	return neo4jDriver
+}
+
+// Close releases every client opened by the Init functions.
+// Call it once on shutdown after in-flight work has drained.
+func Close(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	var errs []error
+	if milvusClient != nil {
+		errs = append(errs, milvusClient.Close(ctx))
+		milvusClient = nil
+	}
+	if neo4jDriver != nil {
+		errs = append(errs, neo4jDriver.Close(ctx))
+		neo4jDriver = nil
+	}
+	return errors.Join(errs...)
+}
+
+func setMilvus(c *milvusclient.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	milvusClient = c
+}
+
+func setNeo4j(d neo4j.DriverWithContext) {
+	mu.Lock()
+	defer mu.Unlock()
+	neo4jDriver = d
+}
@@ -0,0 +1,126 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"sea/config"
+	schema "sea/embedding/schema/vector"
+	"sea/zlog"
+
+	"github.com/milvus-io/milvus/client/v2/index"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"go.uber.org/zap"
+)
+
+// EnsureRecallCollections makes sure both recall collections exist with their
+// vector index and the inverted index on tag, then loads them. It is safe to
+// run on every start: existing collections and indexes are left alone, and
+// differences between the live schema and the code are logged and returned
+// instead of being migrated automatically.
+func EnsureRecallCollections(ctx context.Context, client *milvusclient.Client) ([]schema.SchemaDrift, error) {
+	cfg := config.Cfg
+	specs, err := schema.RecallCollections(cfg.EmbeddingDimensions(), cfg.Milvus.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []schema.SchemaDrift
+	for _, spec := range specs {
+		d, err := ensureCollection(ctx, client, spec)
+		if err != nil {
+			return drift, fmt.Errorf("collection %s: %w", spec.Schema.CollectionName, err)
+		}
+		drift = append(drift, d...)
+	}
+	for _, d := range drift {
+		zlog.L().Warn("milvus schema drift", zap.String("drift", d.String()))
+	}
+	return drift, nil
+}
+
+func ensureCollection(ctx context.Context, client *milvusclient.Client, spec schema.CollectionSpec) ([]schema.SchemaDrift, error) {
+	name := spec.Schema.CollectionName
+	has, err := client.HasCollection(ctx, milvusclient.NewHasCollectionOption(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []schema.SchemaDrift
+	if !has {
+		if err := client.CreateCollection(ctx, milvusclient.NewCreateCollectionOption(name, spec.Schema)); err != nil {
+			return nil, fmt.Errorf("create: %w", err)
+		}
+		zlog.L().Info("milvus collection created", zap.String("collection", name))
+	} else {
+		coll, err := client.DescribeCollection(ctx, milvusclient.NewDescribeCollectionOption(name))
+		if err != nil {
+			return nil, fmt.Errorf("describe: %w", err)
+		}
+		drift = schema.DiffSchema(spec.Schema, coll.Schema)
+	}
+
+	if err := ensureIndex(ctx, client, name, schema.FieldVector, schema.VectorIndexName, spec.VectorIndex); err != nil {
+		return drift, err
+	}
+	if err := ensureIndex(ctx, client, name, schema.FieldTag, schema.TagIndexName, index.NewInvertedIndex()); err != nil {
+		return drift, err
+	}
+	// 旧集合没有 sparse 字段时跳过，混合检索在这个集合上不可用
+	if spec.SparseIndex != nil && !missingField(drift, schema.FieldSparse) {
+		if err := ensureIndex(ctx, client, name, schema.FieldSparse, schema.SparseIndexName, spec.SparseIndex); err != nil {
+			return drift, err
+		}
+	}
+
+	task, err := client.LoadCollection(ctx, milvusclient.NewLoadCollectionOption(name))
+	if err != nil {
+		return drift, fmt.Errorf("load: %w", err)
+	}
+	if err := task.Await(ctx); err != nil {
+		return drift, fmt.Errorf("wait load: %w", err)
+	}
+	zlog.L().Info("milvus collection loaded", zap.String("collection", name))
+	return drift, nil
+}
+
+// ensureIndex creates idx on field unless the field is already indexed.
+// An existing index with different parameters is kept and only logged.
+func ensureIndex(ctx context.Context, client *milvusclient.Client, collection, field, name string, idx index.Index) error {
+	existing, err := client.ListIndexes(ctx, milvusclient.NewListIndexOption(collection).WithFieldName(field))
+	if err != nil {
+		return fmt.Errorf("list indexes on %s: %w", field, err)
+	}
+	if len(existing) > 0 {
+		desc, err := client.DescribeIndex(ctx, milvusclient.NewDescribeIndexOption(collection, existing[0]))
+		if err == nil && desc.IndexType() != idx.IndexType() {
+			zlog.L().Warn("milvus index type differs from config",
+				zap.String("collection", collection),
+				zap.String("field", field),
+				zap.String("live", string(desc.IndexType())),
+				zap.String("config", string(idx.IndexType())))
+		}
+		return nil
+	}
+
+	task, err := client.CreateIndex(ctx, milvusclient.NewCreateIndexOption(collection, field, idx).WithIndexName(name))
+	if err != nil {
+		return fmt.Errorf("create index on %s: %w", field, err)
+	}
+	if err := task.Await(ctx); err != nil {
+		return fmt.Errorf("wait index on %s: %w", field, err)
+	}
+	zlog.L().Info("milvus index created",
+		zap.String("collection", collection),
+		zap.String("field", field),
+		zap.String("type", string(idx.IndexType())))
+	return nil
+}
+
+func missingField(drift []schema.SchemaDrift, field string) bool {
+	for _, d := range drift {
+		if d.Field == field && d.Detail == "missing in live collection" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sea/config"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/zlog"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"go.uber.org/zap"
+)
+
+// dimensionProbeText is embedded once at startup to measure the model output
+const dimensionProbeText = "dimension probe"
+
+// CheckVectorDimensions compares the configured embedding dimension with the
+// vector field of every existing recall collection and, when enabled, with
+// the length of a probe embedding. Any mismatch is returned as an error so
+// the service refuses to start instead of failing inserts later.
+func CheckVectorDimensions(ctx context.Context, client *milvusclient.Client, embedder service.Embedder) error {
+	cfg := config.Cfg
+	want := cfg.EmbeddingDimensions()
+	if want <= 0 {
+		return fmt.Errorf("embedding dimension must be positive, got %d", want)
+	}
+
+	var errs []error
+	if embedder.Dimensions() != want {
+		errs = append(errs, fmt.Errorf("embedder reports dimension %d, config wants %d", embedder.Dimensions(), want))
+	}
+
+	for _, name := range []string{schema.RecallCandidateCollection, schema.RecallPreciseCollection} {
+		has, err := client.HasCollection(ctx, milvusclient.NewHasCollectionOption(name))
+		if err != nil {
+			return fmt.Errorf("check collection %s: %w", name, err)
+		}
+		if !has {
+			continue
+		}
+		coll, err := client.DescribeCollection(ctx, milvusclient.NewDescribeCollectionOption(name))
+		if err != nil {
+			return fmt.Errorf("describe collection %s: %w", name, err)
+		}
+		live, err := vectorDim(coll.Schema)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collection %s: %w", name, err))
+			continue
+		}
+		if live != int64(want) {
+			errs = append(errs, fmt.Errorf("collection %s has %s dim %d, config wants %d", name, schema.FieldVector, live, want))
+		}
+	}
+
+	if cfg.Milvus.DimensionProbe {
+		res, err := embedder.EmbedText(ctx, dimensionProbeText)
+		if err != nil {
+			return fmt.Errorf("dimension probe embedding: %w", err)
+		}
+		if len(res.Data) == 0 {
+			errs = append(errs, errors.New("dimension probe returned no embedding"))
+		} else if got := len(res.Data[0].Embedding); got != want {
+			errs = append(errs, fmt.Errorf("probe embedding has %d dims, config wants %d", got, want))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		zlog.L().Error("vector dimension mismatch", zap.Error(err))
+		return err
+	}
+	zlog.L().Info("vector dimension check passed", zap.Int("dimension", want))
+	return nil
+}
+
+// vectorDim returns the dim of the recall vector field
+func vectorDim(s *entity.Schema) (int64, error) {
+	for _, f := range s.Fields {
+		if f.Name == schema.FieldVector {
+			return f.GetDim()
+		}
+	}
+	return 0, fmt.Errorf("no %s field", schema.FieldVector)
+}
@@ -3,6 +3,7 @@ package infra
 import (
 	"context"
 	"sea/config"
+	"sea/embedding/service"
 	"strings"
 
 	"github.com/milvus-io/milvus/client/v2/milvusclient"
@@ -11,15 +12,39 @@ import (
 func MilvusInit() error {
 	ctx := context.Background()
 	cfg := config.Cfg
-	client, err := milvusclient.New(ctx, &milvusclient.ClientConfig{
+	connectCtx := ctx
+	if cfg.Milvus.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		connectCtx, cancel = context.WithTimeout(ctx, cfg.Milvus.ConnectTimeout)
+		defer cancel()
+	}
+	clientCfg := &milvusclient.ClientConfig{
 		Address:  cfg.Milvus.Address,
 		Username: cfg.Milvus.Username,
 		Password: cfg.Milvus.Password,
-	})
+	}
+	if cfg.Milvus.RateLimitRetries > 0 {
+		clientCfg.RetryRateLimit = &milvusclient.RetryRateLimitOption{
+			MaxRetry:   uint(cfg.Milvus.RateLimitRetries),
+			MaxBackoff: cfg.Milvus.RateLimitMaxBackoff,
+		}
+	}
+	client, err := milvusclient.New(connectCtx, clientCfg)
 	if err != nil {
 		return err
 	}
 
+	if err := setupMilvus(ctx, client); err != nil {
+		_ = client.Close(ctx)
+		return err
+	}
+	setMilvus(client)
+	return nil
+}
+
+// setupMilvus selects the database and prepares the recall collections
+func setupMilvus(ctx context.Context, client *milvusclient.Client) error {
+	cfg := config.Cfg
 	db := strings.TrimSpace(cfg.Milvus.DBName)
 	if db == "" {
 		db = "default"
@@ -36,6 +61,11 @@ func MilvusInit() error {
 	if err := client.UseDatabase(ctx, milvusclient.NewUseDatabaseOption(db)); err != nil {
 		return err
 	}
-	
-	return nil
+
+	if err := CheckVectorDimensions(ctx, client, service.Default()); err != nil {
+		return err
+	}
+
+	_, err := EnsureRecallCollections(ctx, client)
+	return err
 }
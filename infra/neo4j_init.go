@@ -3,29 +3,47 @@ package infra
 import (
 	"context"
 	"sea/config"
+	"sea/embedding/schema/graph"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	neo4jconfig "github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
 )
 
 func Neo4jInit() error {
 	cfg := config.Cfg
 	ctx := context.Background()
 	client, err := neo4j.NewDriverWithContext(
-
 		cfg.Neo4j.Address,
 		neo4j.BasicAuth(
 			cfg.Neo4j.Username,
 			cfg.Neo4j.Password,
 			"",
 		),
+		func(c *neo4jconfig.Config) {
+			if cfg.Neo4j.MaxConnectionPoolSize > 0 {
+				c.MaxConnectionPoolSize = cfg.Neo4j.MaxConnectionPoolSize
+			}
+			if cfg.Neo4j.ConnectionAcquisitionTimeout > 0 {
+				c.ConnectionAcquisitionTimeout = cfg.Neo4j.ConnectionAcquisitionTimeout
+			}
+			if cfg.Neo4j.MaxConnectionLifetime > 0 {
+				c.MaxConnectionLifetime = cfg.Neo4j.MaxConnectionLifetime
+			}
+		},
 	)
 	if err != nil {
 		return err
 	}
-	defer client.Close(ctx)
 	err = client.VerifyConnectivity(ctx)
 	if err != nil {
+		_ = client.Close(ctx)
+		return err
+	}
+	err = graph.NewRepository(client, cfg.Neo4j.Database).EnsureSchema(ctx)
+	if err != nil {
+		_ = client.Close(ctx)
 		return err
 	}
+	setNeo4j(client)
 	return nil
 }
@@ -0,0 +1,63 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Background work (indexing, edge jobs, ...) is started through Go so that
+// shutdown can wait for it instead of killing it mid-write.
+var (
+	workerMu     sync.Mutex
+	workerWG     sync.WaitGroup
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+	stopping     bool
+)
+
+// ErrShuttingDown is returned by Go once StopWorkers has been called
+var ErrShuttingDown = errors.New("infra: shutting down")
+
+func init() {
+	workerCtx, workerCancel = context.WithCancel(context.Background())
+}
+
+// Go runs fn in a tracked goroutine. The ctx passed to fn is cancelled only
+// when the shutdown drain times out, so fn should finish its current unit of
+// work and return when ctx is done.
+func Go(fn func(ctx context.Context)) error {
+	workerMu.Lock()
+	defer workerMu.Unlock()
+	if stopping {
+		return ErrShuttingDown
+	}
+	workerWG.Add(1)
+	go func() {
+		defer workerWG.Done()
+		fn(workerCtx)
+	}()
+	return nil
+}
+
+// StopWorkers refuses new work and waits for running workers. When ctx ends
+// first the workers are cancelled and ctx.Err() is returned.
+func StopWorkers(ctx context.Context) error {
+	workerMu.Lock()
+	stopping = true
+	workerMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		workerWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		workerCancel()
+		return nil
+	case <-ctx.Done():
+		workerCancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,230 @@
+// Package ingest turns an article into chunk vectors in milvus and chunk
+// nodes in neo4j.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sea/embedding/chunker"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/embedding/vecutil"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidArticle wraps input problems, which callers report as 400
+var ErrInvalidArticle = errors.New("ingest: invalid article")
+
+// Article is one document to ingest. CreatedAt defaults to the ingest time.
+type Article struct {
+	ArticleID string
+	Title     string
+	Body      string
+	Tags      []string
+	CreatedAt time.Time
+}
+
+// Result lists the chunk ids now stored for the article
+type Result struct {
+	ArticleID      string   `json:"article_id"`
+	ParentChunkIDs []string `json:"parent_chunk_ids"`
+	ChildChunkIDs  []string `json:"child_chunk_ids"`
+}
+
+// GraphStore is the part of graph.Repository used by ingest
+type GraphStore interface {
+	UpsertParents(ctx context.Context, parents []graph.ParentNode) error
+	UpsertChildren(ctx context.Context, children []graph.ChildNode) error
+	PruneArticle(ctx context.Context, articleID string, keep []string) (int64, error)
+}
+
+// VectorStore writes chunk vectors into both recall collections
+type VectorStore interface {
+	Upsert(ctx context.Context, rows []VectorRow) error
+	PruneArticle(ctx context.Context, articleID string, keep []string) error
+}
+
+// VectorRow is one child chunk as stored in milvus
+type VectorRow struct {
+	ID        string
+	Vector    []float32
+	Tag       string
+	ArticleID string
+	ParentID  string
+	NodeID    string
+	Text      string
+	CreatedAt int64
+}
+
+// Service runs the ingest pipeline: chunk, embed, write graph, write
+// vectors, then prune chunks left over from a previous version.
+type Service struct {
+	embedder    service.Embedder
+	vectors     VectorStore
+	graph       GraphStore
+	chunking    chunker.Options
+	concurrency int
+	annotators  []Annotator
+	hooks       []func(ctx context.Context, res Result)
+
+	// 同一篇文章的写入串行，防止并发重复提交互相删掉对方的分块
+	locks [64]sync.Mutex
+}
+
+func NewService(embedder service.Embedder, vectors VectorStore, graph GraphStore, chunking chunker.Options, concurrency int) *Service {
+	return &Service{
+		embedder:    embedder,
+		vectors:     vectors,
+		graph:       graph,
+		chunking:    chunking,
+		concurrency: concurrency,
+	}
+}
+
+// Annotator enriches the chunks, e.g. with keywords, before they are
+// embedded and written. The returned commit runs only once the article is
+// stored, under the article lock, so state shared across articles never
+// sees an ingest that failed.
+type Annotator func(res *chunker.Result) (commit func())
+
+// WithAnnotator adds an annotation step
+func (s *Service) WithAnnotator(fn Annotator) *Service {
+	s.annotators = append(s.annotators, fn)
+	return s
+}
+
+// OnStored adds a hook run after an article was stored successfully. Hooks
+// run on the request goroutine; start background work for anything slow.
+func (s *Service) OnStored(fn func(ctx context.Context, res Result)) *Service {
+	s.hooks = append(s.hooks, fn)
+	return s
+}
+
+// Ingest stores the article and replaces any earlier version of it. Writes
+// are upserts keyed by chunk id, so a failed ingest can simply be retried.
+func (s *Service) Ingest(ctx context.Context, article Article) (Result, error) {
+	chunks, err := chunker.Split(chunker.Article{
+		ArticleID: article.ArticleID,
+		Title:     article.Title,
+		Body:      article.Body,
+		Tags:      article.Tags,
+	}, s.chunking)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %w", ErrInvalidArticle, err)
+	}
+	articleID := chunks.Parents[0].Node.ArticleID
+	commits := make([]func(), 0, len(s.annotators))
+	for _, annotate := range s.annotators {
+		commits = append(commits, annotate(&chunks))
+	}
+
+	createdAt := article.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	rows, err := s.embedChildren(ctx, chunks.Children, createdAt.Unix())
+	if err != nil {
+		return Result{}, err
+	}
+
+	lock := s.lock(articleID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	parents := chunks.ParentNodes()
+	children := chunks.ChildNodes()
+	if err := s.graph.UpsertParents(ctx, parents); err != nil {
+		return Result{}, fmt.Errorf("write parent nodes: %w", err)
+	}
+	if err := s.graph.UpsertChildren(ctx, children); err != nil {
+		return Result{}, fmt.Errorf("write child nodes: %w", err)
+	}
+	if err := s.vectors.Upsert(ctx, rows); err != nil {
+		return Result{}, fmt.Errorf("write vectors: %w", err)
+	}
+
+	res := Result{ArticleID: articleID}
+	for _, p := range parents {
+		res.ParentChunkIDs = append(res.ParentChunkIDs, p.ChunkID)
+	}
+	for _, c := range children {
+		res.ChildChunkIDs = append(res.ChildChunkIDs, c.ChunkID)
+	}
+	if err := s.vectors.PruneArticle(ctx, articleID, res.ChildChunkIDs); err != nil {
+		return Result{}, fmt.Errorf("prune vectors: %w", err)
+	}
+	keep := make([]string, 0, len(parents)+len(children))
+	for _, p := range parents {
+		keep = append(keep, p.NodeID)
+	}
+	for _, c := range children {
+		keep = append(keep, c.NodeID)
+	}
+	if _, err := s.graph.PruneArticle(ctx, articleID, keep); err != nil {
+		return Result{}, fmt.Errorf("prune nodes: %w", err)
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	for _, hook := range s.hooks {
+		hook(ctx, res)
+	}
+	return res, nil
+}
+
+// embedChildren embeds every child chunk before anything is written, so an
+// embedding failure leaves the stored article untouched
+func (s *Service) embedChildren(ctx context.Context, children []chunker.ChildChunk, createdAt int64) ([]VectorRow, error) {
+	texts := make([]string, len(children))
+	for i, c := range children {
+		texts[i] = c.Text
+	}
+	results, err := service.EmbedTextsBatched(ctx, s.embedder, texts, service.BatchOptions{Concurrency: s.concurrency})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]VectorRow, len(children))
+	for i, c := range children {
+		if results[i].Err != nil {
+			return nil, fmt.Errorf("embed chunk %s: %w", c.Node.ChunkID, results[i].Err)
+		}
+		vec, err := vecutil.Prepare(results[i].Embedding, true)
+		if err != nil {
+			return nil, fmt.Errorf("embed chunk %s: %w", c.Node.ChunkID, err)
+		}
+		rows[i] = VectorRow{
+			ID:        c.Node.ChunkID,
+			Vector:    vec,
+			Tag:       c.Node.Tag,
+			ArticleID: c.Node.ArticleID,
+			ParentID:  c.Node.ParentNodeID,
+			NodeID:    c.Node.NodeID,
+			Text:      truncateUTF8(c.Text, schema.TextMaxLength),
+			CreatedAt: createdAt,
+		}
+	}
+	return rows, nil
+}
+
+func (s *Service) lock(articleID string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(articleID))
+	return &s.locks[h.Sum32()%uint32(len(s.locks))]
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,221 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sea/embedding/chunker"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/embedding/vecutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memGraph 内存版图存储
+type memGraph struct {
+	mu    sync.Mutex
+	nodes map[string]string // node id -> article id
+	err   error
+}
+
+func newMemGraph() *memGraph {
+	return &memGraph{nodes: make(map[string]string)}
+}
+
+func (g *memGraph) UpsertParents(ctx context.Context, parents []graph.ParentNode) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return g.err
+	}
+	for _, p := range parents {
+		g.nodes[p.NodeID] = p.ArticleID
+	}
+	return nil
+}
+
+func (g *memGraph) UpsertChildren(ctx context.Context, children []graph.ChildNode) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, c := range children {
+		g.nodes[c.NodeID] = c.ArticleID
+	}
+	return nil
+}
+
+func (g *memGraph) PruneArticle(ctx context.Context, articleID string, keep []string) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var n int64
+	for id, a := range g.nodes {
+		if a == articleID && !contains(keep, id) {
+			delete(g.nodes, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memVectors 内存版向量存储
+type memVectors struct {
+	mu   sync.Mutex
+	rows map[string]VectorRow
+}
+
+func newMemVectors() *memVectors {
+	return &memVectors{rows: make(map[string]VectorRow)}
+}
+
+func (m *memVectors) Upsert(ctx context.Context, rows []VectorRow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range rows {
+		m.rows[r.ID] = r
+	}
+	return nil
+}
+
+func (m *memVectors) PruneArticle(ctx context.Context, articleID string, keep []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.rows {
+		if r.ArticleID == articleID && !contains(keep, id) {
+			delete(m.rows, id)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var smallChunks = chunker.Options{ParentSize: 60, ParentOverlap: 0, ChildSize: 20, ChildOverlap: 0}
+
+// 入库后向量和节点都能对上返回的 ChunkID
+func TestIngestWritesVectorsAndNodes(t *testing.T) {
+	g, v := newMemGraph(), newMemVectors()
+	svc := NewService(service.NewFakeEmbedder(8), v, g, smallChunks, 2)
+
+	res, err := svc.Ingest(context.Background(), Article{
+		ArticleID: "a1",
+		Title:     "t",
+		Body:      strings.Repeat("向量检索很常用。", 12),
+		Tags:      []string{"ai"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a1", res.ArticleID)
+	require.NotEmpty(t, res.ParentChunkIDs)
+	require.NotEmpty(t, res.ChildChunkIDs)
+
+	assert.Len(t, v.rows, len(res.ChildChunkIDs))
+	for _, id := range res.ChildChunkIDs {
+		row := v.rows[id]
+		assert.Len(t, row.Vector, 8)
+		assert.Equal(t, "ai", row.Tag)
+		assert.NotEmpty(t, row.ParentID)
+		assert.NotZero(t, row.CreatedAt)
+	}
+	assert.Len(t, g.nodes, len(res.ParentChunkIDs)+len(res.ChildChunkIDs))
+}
+
+// 同一篇文章重复提交会替换旧分块而不是叠加
+func TestIngestIsIdempotent(t *testing.T) {
+	g, v := newMemGraph(), newMemVectors()
+	svc := NewService(service.NewFakeEmbedder(8), v, g, smallChunks, 2)
+	ctx := context.Background()
+
+	_, err := svc.Ingest(ctx, Article{ArticleID: "a1", Body: strings.Repeat("很长的一句话。", 20)})
+	require.NoError(t, err)
+	_, err = svc.Ingest(ctx, Article{ArticleID: "other", Body: "别的文章。"})
+	require.NoError(t, err)
+	before := len(v.rows)
+
+	res, err := svc.Ingest(ctx, Article{ArticleID: "a1", Body: "短了。"})
+	require.NoError(t, err)
+	assert.Len(t, res.ChildChunkIDs, 1)
+	assert.Less(t, len(v.rows), before)
+	assert.Len(t, v.rows, 2)
+	assert.Len(t, g.nodes, 4)
+}
+
+func TestIngestRunsAnnotatorsAndHooks(t *testing.T) {
+	g, v := newMemGraph(), newMemVectors()
+	var annotated int
+	var committed, stored []string
+	svc := NewService(service.NewFakeEmbedder(8), v, g, smallChunks, 2).
+		WithAnnotator(func(res *chunker.Result) func() {
+			annotated += len(res.Children)
+			id := res.Parents[0].Node.ArticleID
+			return func() { committed = append(committed, id) }
+		}).
+		OnStored(func(ctx context.Context, res Result) {
+			stored = append(stored, res.ArticleID)
+		})
+
+	res, err := svc.Ingest(context.Background(), Article{ArticleID: "a1", Body: strings.Repeat("图谱扩展。", 10)})
+	require.NoError(t, err)
+	assert.Equal(t, len(res.ChildChunkIDs), annotated)
+	assert.Equal(t, []string{"a1"}, committed)
+	assert.Equal(t, []string{"a1"}, stored)
+
+	// 写入失败时不提交标注也不触发回调
+	g.err = errors.New("neo4j down")
+	_, err = svc.Ingest(context.Background(), Article{ArticleID: "a2", Body: "失败。"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"a1"}, committed)
+	assert.Equal(t, []string{"a1"}, stored)
+}
+
+// 非法文章报 ErrInvalidArticle，图写入失败不写向量
+func TestIngestErrors(t *testing.T) {
+	g, v := newMemGraph(), newMemVectors()
+	svc := NewService(service.NewFakeEmbedder(8), v, g, smallChunks, 2)
+
+	_, err := svc.Ingest(context.Background(), Article{ArticleID: "a1", Body: "  "})
+	assert.ErrorIs(t, err, ErrInvalidArticle)
+
+	g.err = errors.New("neo4j down")
+	_, err = svc.Ingest(context.Background(), Article{ArticleID: "a1", Body: "正文。"})
+	assert.ErrorContains(t, err, "neo4j down")
+	assert.Empty(t, v.rows)
+}
+
+// 删除表达式对文章 ID 做转义
+func TestPruneExpr(t *testing.T) {
+	assert.Equal(t, `article_id == "a\"1" and id not in ["x", "y"]`, pruneExpr(`a"1`, []string{"x", "y"}))
+	assert.Equal(t, `article_id == "a1"`, pruneExpr("a1", nil))
+}
+
+// 粗召回集合写 float16，精排集合写 float32
+func TestVectorColumn(t *testing.T) {
+	vectors := [][]float32{{0.5, -0.25}, {1, 0}}
+
+	candidate := vectorColumn(schema.RecallCandidateCollection, 2, vectors)
+	assert.Equal(t, entity.FieldTypeFloat16Vector, candidate.Type())
+	assert.Equal(t, 2, candidate.Len())
+	row, err := candidate.Get(0)
+	require.NoError(t, err)
+	assert.Equal(t, vecutil.ToFloat16(vectors[0]), []byte(row.(entity.Float16Vector)))
+
+	precise := vectorColumn(schema.RecallPreciseCollection, 2, vectors)
+	assert.Equal(t, entity.FieldTypeFloatVector, precise.Type())
+}
+
+// 截断文本不拆开多字节字符
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "abc", truncateUTF8("abc", 5))
+	assert.Equal(t, "向", truncateUTF8("向量", 4))
+	assert.Equal(t, "", truncateUTF8("向量", 2))
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/vecutil"
+	"sea/recall"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+// upsertBatchSize keeps one upsert request well below the grpc message limit
+const upsertBatchSize = 256
+
+// MilvusStore writes every chunk vector to both recall collections, as
+// float16 to the candidate collection and float32 to the precise one
+type MilvusStore struct {
+	client      *milvusclient.Client
+	collections []string
+}
+
+func NewMilvusStore(client *milvusclient.Client) *MilvusStore {
+	return &MilvusStore{
+		client:      client,
+		collections: []string{schema.RecallCandidateCollection, schema.RecallPreciseCollection},
+	}
+}
+
+func (m *MilvusStore) Upsert(ctx context.Context, rows []VectorRow) error {
+	if m.client == nil {
+		return errors.New("ingest: milvus client not initialized")
+	}
+	for start := 0; start < len(rows); start += upsertBatchSize {
+		batch := rows[start:min(start+upsertBatchSize, len(rows))]
+		for _, name := range m.collections {
+			if _, err := m.client.Upsert(ctx, upsertOption(name, batch)); err != nil {
+				return fmt.Errorf("upsert %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PruneArticle deletes the article's vectors whose id is not in keep
+func (m *MilvusStore) PruneArticle(ctx context.Context, articleID string, keep []string) error {
+	if m.client == nil {
+		return errors.New("ingest: milvus client not initialized")
+	}
+	expr := pruneExpr(articleID, keep)
+	for _, name := range m.collections {
+		if _, err := m.client.Delete(ctx, milvusclient.NewDeleteOption(name).WithExpr(expr)); err != nil {
+			return fmt.Errorf("delete from %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func pruneExpr(articleID string, keep []string) string {
+	expr := fmt.Sprintf("%s == %s", schema.FieldArticleID, recall.StringLiteral(articleID))
+	if len(keep) > 0 {
+		expr += fmt.Sprintf(" and %s not in %s", schema.FieldID, recall.StringList(keep))
+	}
+	return expr
+}
+
+func upsertOption(collection string, rows []VectorRow) milvusclient.UpsertOption {
+	n := len(rows)
+	ids := make([]string, n)
+	vectors := make([][]float32, n)
+	tags := make([]string, n)
+	articleIDs := make([]string, n)
+	parentIDs := make([]string, n)
+	nodeIDs := make([]string, n)
+	texts := make([]string, n)
+	createdAt := make([]int64, n)
+	for i, r := range rows {
+		ids[i] = r.ID
+		vectors[i] = r.Vector
+		tags[i] = r.Tag
+		articleIDs[i] = r.ArticleID
+		parentIDs[i] = r.ParentID
+		nodeIDs[i] = r.NodeID
+		texts[i] = r.Text
+		createdAt[i] = r.CreatedAt
+	}
+	dim := 0
+	if n > 0 {
+		dim = len(vectors[0])
+	}
+	// 不在 schema 里的列由客户端并进动态字段
+	return milvusclient.NewColumnBasedInsertOption(collection).
+		WithVarcharColumn(schema.FieldID, ids).
+		WithColumns(vectorColumn(collection, dim, vectors)).
+		WithVarcharColumn(schema.FieldTag, tags).
+		WithVarcharColumn(schema.FieldArticleID, articleIDs).
+		WithVarcharColumn(schema.FieldParentID, parentIDs).
+		WithVarcharColumn(schema.FieldNodeID, nodeIDs).
+		WithVarcharColumn(schema.FieldText, texts).
+		WithInt64Column(schema.FieldCreatedAt, createdAt)
+}
+
+// vectorColumn encodes vectors as the collection's vector field stores them
+func vectorColumn(collection string, dim int, vectors [][]float32) column.Column {
+	if schema.VectorType(collection) == entity.FieldTypeFloat16Vector {
+		rows := make([][]byte, len(vectors))
+		for i, v := range vectors {
+			rows[i] = vecutil.ToFloat16(v)
+		}
+		return column.NewColumnFloat16Vector(schema.FieldVector, dim, rows)
+	}
+	return column.NewColumnFloatVector(schema.FieldVector, dim, vectors)
+}
@@ -1,47 +1,150 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"os/signal"
+	"sea/api"
 	"sea/config"
+	"sea/embedding/keyword"
+	"sea/embedding/service"
 	"sea/infra"
 	"sea/zlog"
+	"syscall"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+const (
+	defaultAddress         = ":8080"
+	defaultShutdownTimeout = 30 * time.Second
+)
+
 func main() {
 	zlog.InitLogger("./log/Recommand.log", "debug")
 	zlog.L().Info("service started")
-	defer zlog.Sync()
 
+	// run 里的 defer 都执行完之后再刷日志退出，panic 会跳过这些
+	err := run()
+	if err != nil {
+		zlog.L().Error("service exited", zap.Error(err))
+	} else {
+		zlog.L().Info("service stopped")
+	}
+	zlog.Sync()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run() error {
 	err := config.Load("./config.yaml")
 	if err != nil {
 		zlog.L().Error("config load failed",
 			zap.Error(err))
-		panic(err)
+		return err
 	}
+	err = service.Init(config.Cfg)
+	if err != nil {
+		zlog.L().Error("embedding service init failed",
+			zap.Error(err))
+		return err
+	}
+	defer service.Close()
+	err = keyword.Init(config.Cfg.Keyword)
+	if err != nil {
+		zlog.L().Error("keyword corpus load failed",
+			zap.Error(err))
+		return err
+	}
+	defer func() {
+		if err := keyword.Close(); err != nil {
+			zlog.L().Error("keyword corpus save failed", zap.Error(err))
+		}
+	}()
+	defer keyword.StartFlusher(config.Cfg.Keyword.FlushInterval)()
 	err = infra.MilvusInit()
 	if err != nil {
 		zlog.L().Error("milvus init failed",
 			zap.Error(err))
-		panic(err)
+		return err
 	}
+	defer closeInfra()
 	err = infra.Neo4jInit()
 	if err != nil {
 		zlog.L().Error("neo4j init failed",
 			zap.Error(err))
-		panic(err)
-	}
-	// 临时这么写，之后改
-	router := gin.Default()
-	router.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "pong",
-		})
-	})
-	if err := router.Run(); err != nil {
+		return err
+	}
+
+	return serve(api.NewRouter(config.Cfg), config.Cfg.Server)
+}
+
+// serve blocks until SIGINT/SIGTERM, then stops accepting connections and
+// drains in-flight requests and background workers within ShutdownTimeout.
+func serve(handler http.Handler, cfg config.ServerConfig) error {
+	addr := cfg.Address
+	if addr == "" {
+		addr = defaultAddress
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		zlog.L().Info("http server listening", zap.String("address", addr))
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// 没收到信号就退出，说明监听失败
 		zlog.L().Error("http server run failed", zap.Error(err))
-		panic(err)
+		return err
+	case <-ctx.Done():
+	}
+	// 再来一次信号就直接退出
+	stop()
+	zlog.L().Info("shutting down")
+
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		zlog.L().Error("http server shutdown failed", zap.Error(err))
+		errs = append(errs, err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	if err := infra.StopWorkers(shutdownCtx); err != nil {
+		zlog.L().Error("background workers did not finish", zap.Error(err))
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func closeInfra() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := infra.Close(ctx); err != nil {
+		zlog.L().Error("infra close failed", zap.Error(err))
 	}
 }
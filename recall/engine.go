@@ -0,0 +1,318 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sea/config"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/embedding/vecutil"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// Search modes
+const (
+	// ModeChunk returns child chunks through the candidate -> precise funnel
+	ModeChunk = "chunk"
+	// ModeParent returns parent chunks with their matching children
+	ModeParent = "parent"
+	// ModeHybrid fuses dense and BM25 results on the precise collection
+	ModeHybrid = "hybrid"
+)
+
+// Defaults when neither the request nor the config sets a value. The caps
+// stay below the milvus topk limit of 16384.
+const (
+	defaultTopK          = 10
+	defaultCandidateTopK = 100
+	defaultMaxTopK       = 100
+	defaultMaxCandidate  = 2000
+)
+
+// ErrInvalidQuery wraps request problems, which callers report as 400
+var ErrInvalidQuery = errors.New("recall: invalid query")
+
+// Query is one search request
+type Query struct {
+	Text string
+	Mode string
+	// TopK is the number of results, CandidateTopK the width of the coarse
+	// stage; zero uses the configured defaults
+	TopK          int
+	CandidateTopK int
+	// Filter applies to both stages
+	Filter *Filter
+	// Fusion configures ModeHybrid, RRF with k=60 when nil
+	Fusion *Fusion
+	// Expand adds graph neighbors of the hits; not used in ModeParent
+	Expand *ExpandOptions
+}
+
+// StageTiming is the wall time of one pipeline stage
+type StageTiming struct {
+	Stage  string  `json:"stage"`
+	Millis float64 `json:"ms"`
+	Hits   int     `json:"hits"`
+}
+
+// Response carries Hits in chunk mode and Parents in parent mode
+type Response struct {
+	Mode    string         `json:"mode"`
+	Hits    []Hit          `json:"hits,omitempty"`
+	Parents []ParentResult `json:"parents,omitempty"`
+	Timings []StageTiming  `json:"timings"`
+}
+
+// Engine embeds queries and runs them through the recall stages
+type Engine struct {
+	embedder service.Embedder
+	vectors  Searcher
+	graph    Graph
+	parents  *ParentRetriever
+	metric   entity.MetricType
+	cfg      config.RecallConfig
+}
+
+func NewEngine(embedder service.Embedder, vectors Searcher, graph Graph, metric entity.MetricType, cfg config.RecallConfig) *Engine {
+	return &Engine{
+		embedder: embedder,
+		vectors:  vectors,
+		graph:    graph,
+		parents:  NewParentRetriever(vectors, graph, metric),
+		metric:   metric,
+		cfg:      cfg,
+	}
+}
+
+func (e *Engine) Search(ctx context.Context, q Query) (Response, error) {
+	q, err := e.normalize(q)
+	if err != nil {
+		return Response{}, err
+	}
+	filter, err := q.Filter.Compile()
+	if err != nil {
+		return Response{}, err
+	}
+	res := Response{Mode: q.Mode}
+
+	start := time.Now()
+	vector, err := e.embedQuery(ctx, q.Text)
+	if err != nil {
+		return Response{}, err
+	}
+	res.Timings = append(res.Timings, timing("embed", start, 1))
+
+	if q.Mode == ModeParent {
+		start = time.Now()
+		res.Parents, err = e.parents.Retrieve(ctx, vector, ParentOptions{TopK: q.TopK, ChildTopK: q.CandidateTopK, Filter: filter})
+		if err != nil {
+			return Response{}, err
+		}
+		res.Timings = append(res.Timings, timing("parent", start, len(res.Parents)))
+		return res, nil
+	}
+
+	if q.Mode == ModeHybrid {
+		hybrid, ok := e.vectors.(HybridSearcher)
+		if !ok {
+			return Response{}, errors.New("recall: hybrid search not supported by searcher")
+		}
+		start = time.Now()
+		res.Hits, err = hybrid.HybridSearch(ctx, HybridRequest{
+			Collection:   schema.RecallPreciseCollection,
+			Vector:       vector,
+			Text:         q.Text,
+			TopK:         q.TopK,
+			RouteTopK:    q.CandidateTopK,
+			Filter:       filter,
+			OutputFields: ChunkFields,
+			Fusion:       *q.Fusion,
+		})
+		if err != nil {
+			return Response{}, err
+		}
+		res.Timings = append(res.Timings, timing("hybrid", start, len(res.Hits)))
+	} else {
+		res.Hits, err = e.twoStage(ctx, vector, q.TopK, q.CandidateTopK, filter, &res.Timings)
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	if q.Expand != nil {
+		start = time.Now()
+		// 混合检索的融合分数越大越好，按 COSINE 处理
+		metric := e.metric
+		if q.Mode == ModeHybrid {
+			metric = entity.COSINE
+		}
+		before := len(res.Hits)
+		res.Hits, err = e.expand(ctx, res.Hits, *q.Expand, metric)
+		if err != nil {
+			return Response{}, err
+		}
+		res.Timings = append(res.Timings, timing("graph", start, len(res.Hits)-before))
+	}
+	return res, nil
+}
+
+// twoStage searches the candidate collection wide, then ranks only those
+// candidates in the precise collection
+func (e *Engine) twoStage(ctx context.Context, vector []float32, topK, candidateTopK int, filter string, timings *[]StageTiming) ([]Hit, error) {
+	start := time.Now()
+	candidates, err := e.vectors.Search(ctx, SearchRequest{
+		Collection: schema.RecallCandidateCollection,
+		Vector:     vector,
+		TopK:       candidateTopK,
+		Filter:     filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("candidate stage: %w", err)
+	}
+	*timings = append(*timings, timing("candidate", start, len(candidates)))
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(candidates))
+	for i, h := range candidates {
+		ids[i] = h.ID
+	}
+	start = time.Now()
+	hits, err := e.vectors.Search(ctx, SearchRequest{
+		Collection:   schema.RecallPreciseCollection,
+		Vector:       vector,
+		TopK:         min(topK, len(ids)),
+		Filter:       And(fmt.Sprintf("%s in %s", schema.FieldID, StringList(ids)), filter),
+		OutputFields: ChunkFields,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("precise stage: %w", err)
+	}
+	*timings = append(*timings, timing("precise", start, len(hits)))
+	return hits, nil
+}
+
+func (e *Engine) embedQuery(ctx context.Context, text string) ([]float32, error) {
+	res, err := e.embedder.EmbedText(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	vectors, err := vecutil.FromResponse(res, true)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.New("embed query: empty response")
+	}
+	return vectors[0], nil
+}
+
+// normalize applies defaults and rejects out of range values
+func (e *Engine) normalize(q Query) (Query, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return q, fmt.Errorf("%w: query is empty", ErrInvalidQuery)
+	}
+	if q.Mode == "" {
+		q.Mode = ModeChunk
+	}
+	if q.Mode != ModeChunk && q.Mode != ModeParent && q.Mode != ModeHybrid {
+		return q, fmt.Errorf("%w: unknown mode %q", ErrInvalidQuery, q.Mode)
+	}
+	if q.Mode == ModeHybrid {
+		fusion, err := normalizeFusion(q.Fusion)
+		if err != nil {
+			return q, err
+		}
+		q.Fusion = &fusion
+	}
+
+	maxTopK := positive(e.cfg.MaxTopK, defaultMaxTopK)
+	maxCandidate := positive(e.cfg.MaxCandidateTopK, defaultMaxCandidate)
+	if q.TopK == 0 {
+		q.TopK = positive(e.cfg.TopK, defaultTopK)
+	}
+	if q.CandidateTopK == 0 {
+		q.CandidateTopK = max(positive(e.cfg.CandidateTopK, defaultCandidateTopK), q.TopK)
+	}
+	if q.TopK < 0 || q.TopK > maxTopK {
+		return q, fmt.Errorf("%w: top_k must be in [1, %d]", ErrInvalidQuery, maxTopK)
+	}
+	if q.CandidateTopK < q.TopK || q.CandidateTopK > maxCandidate {
+		return q, fmt.Errorf("%w: candidate_top_k must be in [top_k, %d]", ErrInvalidQuery, maxCandidate)
+	}
+	// expand defaults to the final top_k, so it goes after the top_k default
+	if q.Expand != nil {
+		if q.Mode == ModeParent {
+			return q, fmt.Errorf("%w: expand is not supported in parent mode", ErrInvalidQuery)
+		}
+		expand, err := q.Expand.normalize(q.TopK, maxTopK)
+		if err != nil {
+			return q, err
+		}
+		q.Expand = &expand
+	}
+	return q, nil
+}
+
+func normalizeFusion(f *Fusion) (Fusion, error) {
+	if f == nil {
+		return Fusion{Method: FusionRRF, K: defaultRRFK}, nil
+	}
+	out := *f
+	switch strings.ToLower(out.Method) {
+	case "", FusionRRF:
+		out.Method = FusionRRF
+		if out.K == 0 {
+			out.K = defaultRRFK
+		}
+		if out.K <= 0 || out.K >= 16384 {
+			return out, fmt.Errorf("%w: fusion k must be in (0, 16384)", ErrInvalidQuery)
+		}
+	case FusionWeighted:
+		out.Method = FusionWeighted
+		if out.DenseWeight == 0 && out.SparseWeight == 0 {
+			out.DenseWeight, out.SparseWeight = 0.5, 0.5
+		}
+		if out.DenseWeight < 0 || out.DenseWeight > 1 || out.SparseWeight < 0 || out.SparseWeight > 1 {
+			return out, fmt.Errorf("%w: fusion weights must be in [0, 1]", ErrInvalidQuery)
+		}
+	default:
+		return out, fmt.Errorf("%w: unknown fusion method %q", ErrInvalidQuery, f.Method)
+	}
+	return out, nil
+}
+
+// And joins non-empty milvus expressions with and, parenthesizing each one
+// when there is more than one
+func And(exprs ...string) string {
+	var parts []string
+	for _, e := range exprs {
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	if len(parts) < 2 {
+		return strings.Join(parts, "")
+	}
+	for i, p := range parts {
+		parts[i] = "(" + p + ")"
+	}
+	return strings.Join(parts, " and ")
+}
+
+func positive(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func timing(stage string, start time.Time, hits int) StageTiming {
+	return StageTiming{Stage: stage, Millis: float64(time.Since(start).Microseconds()) / 1000, Hits: hits}
+}
@@ -0,0 +1,173 @@
+package recall
+
+import (
+	"context"
+	"sea/config"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"sea/embedding/vecutil"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(vectors *stubSearcher, g *stubGraph) *Engine {
+	return NewEngine(service.NewFakeEmbedder(8), vectors, g, entity.COSINE, config.RecallConfig{})
+}
+
+// 先在粗召回集合宽搜，再在精排集合里只排候选
+func TestEngineTwoStage(t *testing.T) {
+	vectors := &stubSearcher{byCollection: map[string][]Hit{
+		schema.RecallCandidateCollection: {{ID: "c1"}, {ID: "c2"}, {ID: "c3"}},
+		schema.RecallPreciseCollection:   {{ID: "c2", Score: 0.9}, {ID: "c1", Score: 0.8}},
+	}}
+	e := newTestEngine(vectors, newStubGraph())
+
+	res, err := e.Search(context.Background(), Query{Text: "向量", TopK: 2, CandidateTopK: 50, Filter: &Filter{Tag: "ai"}})
+	require.NoError(t, err)
+	assert.Equal(t, ModeChunk, res.Mode)
+	require.Len(t, res.Hits, 2)
+	assert.Equal(t, "c2", res.Hits[0].ID)
+
+	require.Len(t, vectors.calls, 2)
+	candidate, precise := vectors.calls[0], vectors.calls[1]
+	assert.Equal(t, 50, candidate.TopK)
+	assert.Len(t, candidate.Vector, 8)
+	assert.Equal(t, `tag == "ai"`, candidate.Filter)
+	assert.Equal(t, 2, precise.TopK)
+	assert.Equal(t, `(id in ["c1", "c2", "c3"]) and (tag == "ai")`, precise.Filter)
+	assert.Equal(t, ChunkFields, precise.OutputFields)
+
+	var stages []string
+	for _, st := range res.Timings {
+		stages = append(stages, st.Stage)
+	}
+	assert.Equal(t, []string{"embed", "candidate", "precise"}, stages)
+}
+
+// 粗召回没有结果时跳过精排
+func TestEngineNoCandidates(t *testing.T) {
+	vectors := &stubSearcher{}
+	e := newTestEngine(vectors, newStubGraph())
+	res, err := e.Search(context.Background(), Query{Text: "q"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Hits)
+	require.Len(t, vectors.calls, 1)
+	assert.Equal(t, defaultCandidateTopK, vectors.calls[0].TopK)
+}
+
+// parent 模式走父子块检索
+func TestEngineParentMode(t *testing.T) {
+	vectors := &stubSearcher{hits: []Hit{{ID: "c1", Score: 0.7}}}
+	e := newTestEngine(vectors, newStubGraph(link("c1", "p1")))
+	res, err := e.Search(context.Background(), Query{Text: "q", Mode: ModeParent, TopK: 3})
+	require.NoError(t, err)
+	require.Len(t, res.Parents, 1)
+	assert.Equal(t, "p1", res.Parents[0].Parent.NodeID)
+	assert.Equal(t, schema.RecallPreciseCollection, vectors.last.Collection)
+}
+
+// 参数校验
+func TestEngineInvalidQuery(t *testing.T) {
+	e := newTestEngine(&stubSearcher{}, newStubGraph())
+	for _, q := range []Query{
+		{Text: "  "},
+		{Text: "q", Mode: "graph"},
+		{Text: "q", TopK: 1000},
+		{Text: "q", TopK: 20, CandidateTopK: 10},
+		{Text: "q", CandidateTopK: 100000},
+	} {
+		_, err := e.Search(context.Background(), q)
+		assert.ErrorIs(t, err, ErrInvalidQuery, "%+v", q)
+	}
+}
+
+// 表达式拼接和字符串转义
+func TestExprHelpers(t *testing.T) {
+	assert.Equal(t, "", And("", ""))
+	assert.Equal(t, "(a) and (b)", And("a", "", "b"))
+	assert.Equal(t, `"x\") or (1 == 1"`, StringLiteral(`x") or (1 == 1`))
+	assert.Equal(t, `["a", "b\\c"]`, StringList([]string{"a", `b\c`}))
+}
+
+// stubHybrid 支持混合检索的测试桩
+type stubHybrid struct {
+	stubSearcher
+	hybrid []HybridRequest
+}
+
+func (s *stubHybrid) HybridSearch(ctx context.Context, req HybridRequest) ([]Hit, error) {
+	s.hybrid = append(s.hybrid, req)
+	return s.hits, s.err
+}
+
+// hybrid 模式在精排集合上同时走稠密和 BM25 两路
+func TestEngineHybridMode(t *testing.T) {
+	vectors := &stubHybrid{stubSearcher: stubSearcher{hits: []Hit{{ID: "c1", Score: 0.03}}}}
+	e := NewEngine(service.NewFakeEmbedder(8), vectors, newStubGraph(), entity.COSINE, config.RecallConfig{})
+
+	res, err := e.Search(context.Background(), Query{
+		Text:   "iPhone 15 Pro",
+		Mode:   ModeHybrid,
+		TopK:   5,
+		Filter: &Filter{Tag: "phone"},
+		Fusion: &Fusion{Method: "WEIGHTED", DenseWeight: 0.3, SparseWeight: 0.7},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	require.Len(t, vectors.hybrid, 1)
+	req := vectors.hybrid[0]
+	assert.Equal(t, schema.RecallPreciseCollection, req.Collection)
+	assert.Equal(t, "iPhone 15 Pro", req.Text)
+	assert.Equal(t, 5, req.TopK)
+	assert.Equal(t, defaultCandidateTopK, req.RouteTopK)
+	assert.Equal(t, `tag == "phone"`, req.Filter)
+	assert.Equal(t, Fusion{Method: FusionWeighted, DenseWeight: 0.3, SparseWeight: 0.7}, req.Fusion)
+	assert.Empty(t, vectors.calls)
+
+	// 默认 RRF
+	_, err = e.Search(context.Background(), Query{Text: "q", Mode: ModeHybrid})
+	require.NoError(t, err)
+	assert.Equal(t, Fusion{Method: FusionRRF, K: defaultRRFK}, vectors.hybrid[1].Fusion)
+}
+
+// 不支持混合检索的 searcher 和非法融合参数报错
+func TestEngineHybridErrors(t *testing.T) {
+	e := newTestEngine(&stubSearcher{}, newStubGraph())
+	_, err := e.Search(context.Background(), Query{Text: "q", Mode: ModeHybrid})
+	assert.ErrorContains(t, err, "hybrid search not supported")
+
+	for _, f := range []Fusion{
+		{Method: "max"},
+		{Method: FusionRRF, K: -1},
+		{Method: FusionWeighted, DenseWeight: 1.5},
+	} {
+		_, err := e.Search(context.Background(), Query{Text: "q", Mode: ModeHybrid, Fusion: &f})
+		assert.ErrorIs(t, err, ErrInvalidQuery, "%+v", f)
+	}
+}
+
+// 融合参数映射到 milvus 重排器
+func TestReranker(t *testing.T) {
+	params := func(f Fusion) map[string]string {
+		out := make(map[string]string)
+		for _, kv := range reranker(f).GetParams() {
+			out[kv.GetKey()] = kv.GetValue()
+		}
+		return out
+	}
+	assert.Equal(t, "rrf", params(Fusion{Method: FusionRRF, K: 30})["strategy"])
+	assert.Contains(t, params(Fusion{Method: FusionRRF, K: 30})["params"], "30")
+	weighted := params(Fusion{Method: FusionWeighted, DenseWeight: 0.3, SparseWeight: 0.7})
+	assert.Equal(t, "weighted", weighted["strategy"])
+	assert.Contains(t, weighted["params"], "0.3")
+}
+
+// 查询向量按集合的向量类型编码
+func TestQueryVector(t *testing.T) {
+	v := []float32{0.5, -0.25}
+	assert.Equal(t, entity.Float16Vector(vecutil.ToFloat16(v)), queryVector(schema.RecallCandidateCollection, v))
+	assert.Equal(t, entity.FloatVector(v), queryVector(schema.RecallPreciseCollection, v))
+}
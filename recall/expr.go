@@ -0,0 +1,22 @@
+package recall
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StringLiteral quotes s as a milvus expression string literal. strconv
+// escapes quotes, backslashes and control characters the same way the
+// expression parser reads them, so s cannot end the literal early.
+func StringLiteral(s string) string {
+	return strconv.Quote(s)
+}
+
+// StringList renders values as a milvus list literal, e.g. ["a", "b"]
+func StringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = StringLiteral(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,187 @@
+package recall
+
+import (
+	"fmt"
+	"math"
+	schema "sea/embedding/schema/vector"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is the search filter DSL. All set conditions must hold. It is
+// compiled into a milvus boolean expression where every value is rendered
+// as a literal and every field name is checked against FilterFields, so no
+// part of the input is ever spliced into the expression as syntax.
+type Filter struct {
+	Tag           string      `json:"tag,omitempty"`
+	Tags          []string    `json:"tags,omitempty"`
+	ArticleID     string      `json:"article_id,omitempty"`
+	ArticleIDs    []string    `json:"article_ids,omitempty"`
+	CreatedAfter  *time.Time  `json:"created_after,omitempty"`
+	CreatedBefore *time.Time  `json:"created_before,omitempty"`
+	Where         []Predicate `json:"where,omitempty"`
+}
+
+// Predicate compares one of FilterFields with a value.
+// Value is a string, number or bool, or a list of them for in/not in.
+type Predicate struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value any    `json:"value"`
+}
+
+const (
+	maxPredicates = 16
+	maxListValues = 1000
+)
+
+var (
+	// FilterFields are the scalar and dynamic fields a predicate may use.
+	// The primary key, text, sparse and vector fields are not filterable.
+	FilterFields = map[string]bool{
+		schema.FieldTag:       true,
+		schema.FieldArticleID: true,
+		schema.FieldParentID:  true,
+		schema.FieldNodeID:    true,
+		schema.FieldCreatedAt: true,
+	}
+
+	comparisons = map[string]bool{"==": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true}
+)
+
+// Compile returns the milvus expression of f, empty when f sets nothing
+func (f *Filter) Compile() (string, error) {
+	if f == nil {
+		return "", nil
+	}
+	var parts []string
+	add := func(expr string) {
+		parts = append(parts, expr)
+	}
+
+	if f.Tag != "" {
+		add(fmt.Sprintf("%s == %s", schema.FieldTag, StringLiteral(f.Tag)))
+	}
+	if len(f.Tags) > 0 {
+		if len(f.Tags) > maxListValues {
+			return "", invalidFilter("tags has more than %d values", maxListValues)
+		}
+		add(fmt.Sprintf("%s in %s", schema.FieldTag, StringList(f.Tags)))
+	}
+	if f.ArticleID != "" {
+		add(fmt.Sprintf("%s == %s", schema.FieldArticleID, StringLiteral(f.ArticleID)))
+	}
+	if len(f.ArticleIDs) > 0 {
+		if len(f.ArticleIDs) > maxListValues {
+			return "", invalidFilter("article_ids has more than %d values", maxListValues)
+		}
+		add(fmt.Sprintf("%s in %s", schema.FieldArticleID, StringList(f.ArticleIDs)))
+	}
+	if f.CreatedAfter != nil {
+		add(fmt.Sprintf("%s >= %d", schema.FieldCreatedAt, f.CreatedAfter.Unix()))
+	}
+	if f.CreatedBefore != nil {
+		add(fmt.Sprintf("%s < %d", schema.FieldCreatedAt, f.CreatedBefore.Unix()))
+	}
+
+	if len(f.Where) > maxPredicates {
+		return "", invalidFilter("more than %d where predicates", maxPredicates)
+	}
+	for _, p := range f.Where {
+		expr, err := p.compile()
+		if err != nil {
+			return "", err
+		}
+		add(expr)
+	}
+	return And(parts...), nil
+}
+
+func (p Predicate) compile() (string, error) {
+	if !FilterFields[p.Field] {
+		return "", invalidFilter("field %q is not filterable", p.Field)
+	}
+
+	op := strings.ToLower(strings.Join(strings.Fields(p.Op), " "))
+	switch {
+	case comparisons[op]:
+		lit, err := scalarLiteral(p.Value)
+		if err != nil {
+			return "", invalidFilter("field %s: %v", p.Field, err)
+		}
+		return fmt.Sprintf("%s %s %s", p.Field, op, lit), nil
+	case op == "in" || op == "not in":
+		list, err := listLiteral(p.Value)
+		if err != nil {
+			return "", invalidFilter("field %s: %v", p.Field, err)
+		}
+		return fmt.Sprintf("%s %s %s", p.Field, op, list), nil
+	case op == "prefix":
+		s, ok := p.Value.(string)
+		if !ok {
+			return "", invalidFilter("field %s: prefix needs a string", p.Field)
+		}
+		return fmt.Sprintf("%s like %s", p.Field, StringLiteral(escapeLike(s)+"%")), nil
+	default:
+		return "", invalidFilter("unsupported op %q", p.Op)
+	}
+}
+
+// scalarLiteral renders a JSON scalar as an expression literal
+func scalarLiteral(v any) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return StringLiteral(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return "", fmt.Errorf("number out of range")
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	default:
+		return "", fmt.Errorf("unsupported value %T", v)
+	}
+}
+
+func listLiteral(v any) (string, error) {
+	var items []any
+	switch x := v.(type) {
+	case []any:
+		items = x
+	case []string:
+		for _, s := range x {
+			items = append(items, s)
+		}
+	default:
+		return "", fmt.Errorf("in needs a list")
+	}
+	if len(items) == 0 || len(items) > maxListValues {
+		return "", fmt.Errorf("list needs 1 to %d values", maxListValues)
+	}
+	lits := make([]string, len(items))
+	for i, item := range items {
+		lit, err := scalarLiteral(item)
+		if err != nil {
+			return "", err
+		}
+		lits[i] = lit
+	}
+	return "[" + strings.Join(lits, ", ") + "]", nil
+}
+
+// escapeLike makes % and _ in a prefix match literally
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	return strings.ReplaceAll(s, "_", `\_`)
+}
+
+func invalidFilter(format string, args ...any) error {
+	return fmt.Errorf("%w: filter: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,98 @@
+package recall
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 各类条件编译成 milvus 表达式
+func TestFilterCompile(t *testing.T) {
+	after := time.Unix(1700000000, 0)
+	f := &Filter{
+		Tags:         []string{"ai", "go"},
+		ArticleID:    "a1",
+		CreatedAfter: &after,
+		Where: []Predicate{
+			{Field: "parent_id", Op: "==", Value: "a1#p0000"},
+			{Field: "created_at", Op: "<", Value: 1.8e9},
+			{Field: "node_id", Op: "NOT  IN", Value: []any{"n1", "n2"}},
+			{Field: "tag", Op: "prefix", Value: "50%_off"},
+		},
+	}
+	expr, err := f.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, `(tag in ["ai", "go"]) and (article_id == "a1") and (created_at >= 1700000000)`+
+		` and (parent_id == "a1#p0000") and (created_at < 1800000000) and (node_id not in ["n1", "n2"]) and (tag like "50\\%\\_off%")`, expr)
+}
+
+// 空过滤器不产生表达式
+func TestFilterEmpty(t *testing.T) {
+	var f *Filter
+	expr, err := f.Compile()
+	require.NoError(t, err)
+	assert.Empty(t, expr)
+
+	expr, err = (&Filter{}).Compile()
+	require.NoError(t, err)
+	assert.Empty(t, expr)
+}
+
+// 值里的引号和反斜杠被转义，不能跳出字符串
+func TestFilterEscapesValues(t *testing.T) {
+	expr, err := (&Filter{Tag: `ai" or tag != "`}).Compile()
+	require.NoError(t, err)
+	assert.Equal(t, `tag == "ai\" or tag != \""`, expr)
+
+	expr, err = (&Filter{Where: []Predicate{{Field: "tag", Op: "==", Value: "a\\\"b\n"}}}).Compile()
+	require.NoError(t, err)
+	assert.Equal(t, `tag == "a\\\"b\n"`, expr)
+}
+
+// 字段名、操作符和值类型都要合法
+func TestFilterRejectsInjection(t *testing.T) {
+	cases := []Predicate{
+		{Field: "tag == \"x\" or 1", Op: "==", Value: "x"},
+		{Field: `$meta["x"]`, Op: "==", Value: "x"},
+		{Field: "or", Op: "==", Value: "x"},
+		{Field: "vector", Op: "==", Value: "x"},
+		{Field: "tag", Op: "== 1 or tag", Value: "x"},
+		{Field: "tag", Op: "in", Value: "x"},
+		{Field: "tag", Op: "in", Value: []any{}},
+		{Field: "tag", Op: "in", Value: []any{map[string]any{"a": 1}}},
+		{Field: "tag", Op: "==", Value: nil},
+		{Field: "tag", Op: "prefix", Value: 1.0},
+	}
+	for _, p := range cases {
+		_, err := (&Filter{Where: []Predicate{p}}).Compile()
+		assert.ErrorIs(t, err, ErrInvalidQuery, "%+v", p)
+	}
+}
+
+// 只有白名单里的标量和动态字段可以过滤
+func TestFilterFieldAllowList(t *testing.T) {
+	for _, field := range []string{"id", "text", "sparse", "vector", "lang", "views"} {
+		_, err := (&Filter{Where: []Predicate{{Field: field, Op: "==", Value: "x"}}}).Compile()
+		assert.ErrorIs(t, err, ErrInvalidQuery, field)
+	}
+	for field := range FilterFields {
+		_, err := (&Filter{Where: []Predicate{{Field: field, Op: "==", Value: "x"}}}).Compile()
+		assert.NoError(t, err, field)
+	}
+}
+
+// JSON 请求体可以直接解码成过滤器
+func TestFilterFromJSON(t *testing.T) {
+	var f Filter
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"tag": "ai",
+		"created_before": "2024-01-01T00:00:00Z",
+		"where": [{"field": "created_at", "op": ">", "value": 100}, {"field": "article_id", "op": "in", "value": ["a1", "a2"]}]
+	}`), &f))
+	expr, err := f.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, `(tag == "ai") and (created_at < 1704067200) and (created_at > 100) and (article_id in ["a1", "a2"])`, expr)
+}
@@ -0,0 +1,194 @@
+package recall
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sort"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// Hit sources
+const (
+	SourceVector = "vector"
+	SourceGraph  = "graph"
+)
+
+const defaultDecay = 0.5
+
+// NeighborGraph walks weighted edges from seed nodes
+type NeighborGraph interface {
+	Expand(ctx context.Context, seeds []string, opts graph.ExpandOptions) ([]graph.Neighbor, error)
+}
+
+// Graph is everything the engine needs from neo4j
+type Graph interface {
+	ParentGraph
+	NeighborGraph
+}
+
+// ExpandOptions turns on graph expansion of the vector hits
+type ExpandOptions struct {
+	// Hops is the max path length, 1 when zero
+	Hops int `json:"hops"`
+	// EdgeTag and EdgeTypes restrict the edges followed
+	EdgeTag   string   `json:"edge_tag,omitempty"`
+	EdgeTypes []string `json:"edge_types,omitempty"`
+	MinWeight float64  `json:"min_weight,omitempty"`
+	// Decay multiplies the score once per hop after the first, 0.5 when zero
+	Decay float64 `json:"decay,omitempty"`
+	// TopK caps the added neighbors, the query top_k when zero
+	TopK int `json:"top_k,omitempty"`
+}
+
+// Provenance explains how a graph hit was reached
+type Provenance struct {
+	Seed    string    `json:"seed"`
+	Path    []string  `json:"path"`
+	Weights []float64 `json:"weights"`
+}
+
+// normalize defaults TopK to the query topK and caps it at maxTopK, the
+// engine's resolved limit
+func (o ExpandOptions) normalize(topK, maxTopK int) (ExpandOptions, error) {
+	if o.Hops == 0 {
+		o.Hops = 1
+	}
+	if o.Hops < 1 || o.Hops > graph.MaxHops {
+		return o, fmt.Errorf("%w: expand hops must be in [1, %d]", ErrInvalidQuery, graph.MaxHops)
+	}
+	if o.Decay == 0 {
+		o.Decay = defaultDecay
+	}
+	if o.Decay < 0 || o.Decay > 1 {
+		return o, fmt.Errorf("%w: expand decay must be in (0, 1]", ErrInvalidQuery)
+	}
+	if o.TopK == 0 {
+		o.TopK = topK
+	}
+	if o.TopK < 0 || o.TopK > maxTopK {
+		return o, fmt.Errorf("%w: expand top_k must be in [1, %d]", ErrInvalidQuery, maxTopK)
+	}
+	return o, nil
+}
+
+// expand adds the graph neighbors of hits and returns the merged list
+func (e *Engine) expand(ctx context.Context, hits []Hit, opts ExpandOptions, metric entity.MetricType) ([]Hit, error) {
+	if len(hits) == 0 {
+		return hits, nil
+	}
+	seeds := make([]string, 0, len(hits))
+	seedScores := make(map[string]float64, len(hits))
+	for i := range hits {
+		hits[i].Source = SourceVector
+		id := nodeID(hits[i])
+		seeds = append(seeds, id)
+		seedScores[id] = similarity(metric, hits[i].Score)
+	}
+	neighbors, err := e.graph.Expand(ctx, seeds, graph.ExpandOptions{
+		Hops:       opts.Hops,
+		Tag:        opts.EdgeTag,
+		Types:      opts.EdgeTypes,
+		MinWeight:  opts.MinWeight,
+		SeedScores: seedScores,
+		Decay:      opts.Decay,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("graph expand: %w", err)
+	}
+	return mergeNeighbors(hits, neighbors, metric, opts), nil
+}
+
+// mergeNeighbors scores every neighbor by its best path,
+// seed similarity * product(edge weights) * decay^(hops-1), keeps the
+// opts.TopK best that are not already hits, and ranks them together with
+// the hits by similarity
+func mergeNeighbors(hits []Hit, neighbors []graph.Neighbor, metric entity.MetricType, opts ExpandOptions) []Hit {
+	seedScore := make(map[string]float64, len(hits))
+	seen := make(map[string]bool, len(hits))
+	for _, h := range hits {
+		id := nodeID(h)
+		seedScore[id] = similarity(metric, h.Score)
+		seen[id] = true
+	}
+
+	best := make(map[string]Hit)
+	for _, n := range neighbors {
+		if seen[n.NodeID] {
+			continue
+		}
+		score := seedScore[n.Seed]
+		for _, w := range n.Weights {
+			score *= w
+		}
+		if hops := len(n.Weights); hops > 1 {
+			score *= math.Pow(opts.Decay, float64(hops-1))
+		}
+		if cur, ok := best[n.NodeID]; ok && float64(cur.Score) >= score {
+			continue
+		}
+		id := n.ChunkID
+		if id == "" {
+			id = n.NodeID
+		}
+		best[n.NodeID] = Hit{
+			ID:    id,
+			Score: float32(score),
+			Fields: map[string]any{
+				schema.FieldNodeID:    n.NodeID,
+				schema.FieldArticleID: n.ArticleID,
+				schema.FieldTag:       n.Tag,
+				"title":               n.Title,
+			},
+			Source:     SourceGraph,
+			Provenance: &Provenance{Seed: n.Seed, Path: n.Path, Weights: n.Weights},
+		}
+	}
+
+	added := make([]Hit, 0, len(best))
+	for _, h := range best {
+		added = append(added, h)
+	}
+	sort.Slice(added, func(a, b int) bool {
+		if added[a].Score != added[b].Score {
+			return added[a].Score > added[b].Score
+		}
+		return added[a].ID < added[b].ID
+	})
+	if len(added) > opts.TopK {
+		added = added[:opts.TopK]
+	}
+
+	merged := append(append([]Hit(nil), hits...), added...)
+	rank := func(h Hit) float64 {
+		if h.Source == SourceGraph {
+			return float64(h.Score)
+		}
+		return similarity(metric, h.Score)
+	}
+	sort.SliceStable(merged, func(a, b int) bool {
+		return rank(merged[a]) > rank(merged[b])
+	})
+	return merged
+}
+
+// similarity maps a raw score onto larger-is-better. L2 distances become
+// 1/(1+d); COSINE and IP scores are used as they are.
+func similarity(metric entity.MetricType, score float32) float64 {
+	if metric == entity.L2 {
+		return 1 / (1 + float64(score))
+	}
+	return float64(score)
+}
+
+// nodeID is the graph node of a chunk hit, its chunk id unless the
+// node_id field says otherwise
+func nodeID(h Hit) string {
+	if id, ok := h.Fields[schema.FieldNodeID].(string); ok && id != "" {
+		return id
+	}
+	return h.ID
+}
@@ -0,0 +1,108 @@
+package recall
+
+import (
+	"context"
+	"sea/config"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/service"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func neighbor(seed, id string, weights ...float64) graph.Neighbor {
+	return graph.Neighbor{Seed: seed, NodeID: id, ChunkID: id, ArticleID: "a", Path: []string{seed, id}, Weights: weights}
+}
+
+// 邻居分数 = 种子相似度 × 边权乘积 × 衰减^(跳数-1)，取最好的路径
+func TestMergeNeighbors(t *testing.T) {
+	hits := []Hit{{ID: "s1", Score: 0.9}, {ID: "s2", Score: 0.6}}
+	neighbors := []graph.Neighbor{
+		neighbor("s1", "n1", 0.5),      // 0.45
+		neighbor("s2", "n1", 0.9),      // 0.54，更好
+		neighbor("s1", "n2", 1.0, 0.8), // 0.9*0.8*0.5 = 0.36
+		neighbor("s1", "s2", 1.0),      // 已经是命中，跳过
+		neighbor("s2", "n3", 0.1),      // 0.05，超过 TopK 被丢弃
+	}
+	merged := mergeNeighbors(hits, neighbors, entity.COSINE, ExpandOptions{Decay: 0.5, TopK: 2})
+
+	var ids []string
+	for _, h := range merged {
+		ids = append(ids, h.ID)
+	}
+	assert.Equal(t, []string{"s1", "s2", "n1", "n2"}, ids)
+
+	n1 := merged[2]
+	assert.Equal(t, SourceGraph, n1.Source)
+	assert.InDelta(t, 0.54, n1.Score, 1e-6)
+	require.NotNil(t, n1.Provenance)
+	assert.Equal(t, "s2", n1.Provenance.Seed)
+	assert.Equal(t, "n1", n1.Fields[schema.FieldNodeID])
+	assert.InDelta(t, 0.36, merged[3].Score, 1e-6)
+}
+
+// L2 距离先换成相似度再计算
+func TestSimilarity(t *testing.T) {
+	assert.InDelta(t, 0.5, similarity(entity.L2, 1), 1e-9)
+	assert.InDelta(t, 0.8, similarity(entity.COSINE, 0.8), 1e-6)
+}
+
+// 引擎在向量命中后做图扩展，种子优先用 node_id 字段
+func TestEngineExpand(t *testing.T) {
+	vectors := &stubSearcher{byCollection: map[string][]Hit{
+		schema.RecallCandidateCollection: {{ID: "c1"}},
+		schema.RecallPreciseCollection:   {{ID: "c1", Score: 0.8, Fields: map[string]any{schema.FieldNodeID: "node-c1"}}},
+	}}
+	g := newStubGraph()
+	g.neighbors = []graph.Neighbor{neighbor("node-c1", "c9", 0.5)}
+	e := newTestEngine(vectors, g)
+
+	res, err := e.Search(context.Background(), Query{Text: "q", TopK: 1, Expand: &ExpandOptions{Hops: 2, EdgeTag: "kw"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-c1"}, g.expanded)
+	assert.Equal(t, graph.ExpandOptions{
+		Hops:       2,
+		Tag:        "kw",
+		SeedScores: map[string]float64{"node-c1": float64(float32(0.8))},
+		Decay:      defaultDecay,
+	}, g.expandOpt)
+	require.Len(t, res.Hits, 2)
+	assert.Equal(t, SourceVector, res.Hits[0].Source)
+	assert.Equal(t, "c9", res.Hits[1].ID)
+	assert.Equal(t, "graph", res.Timings[len(res.Timings)-1].Stage)
+
+	// 不传 top_k 时扩展也用默认的 top_k
+	g.expanded = nil
+	res, err = e.Search(context.Background(), Query{Text: "q", Expand: &ExpandOptions{}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-c1"}, g.expanded)
+	require.Len(t, res.Hits, 2)
+	assert.Equal(t, "c9", res.Hits[1].ID)
+
+	for _, q := range []Query{
+		{Text: "q", Mode: ModeParent, Expand: &ExpandOptions{}},
+		{Text: "q", Expand: &ExpandOptions{Hops: 5}},
+		{Text: "q", Expand: &ExpandOptions{Decay: 2}},
+		{Text: "q", Expand: &ExpandOptions{TopK: defaultMaxTopK + 1}},
+	} {
+		_, err := e.Search(context.Background(), q)
+		assert.ErrorIs(t, err, ErrInvalidQuery, "%+v", q)
+	}
+}
+
+// 扩展的 top_k 上限跟随配置的 max_top_k
+func TestEngineExpandMaxTopK(t *testing.T) {
+	vectors := &stubSearcher{byCollection: map[string][]Hit{
+		schema.RecallCandidateCollection: {{ID: "c1"}},
+		schema.RecallPreciseCollection:   {{ID: "c1", Score: 0.8}},
+	}}
+	e := NewEngine(service.NewFakeEmbedder(8), vectors, newStubGraph(), entity.COSINE, config.RecallConfig{MaxTopK: 500})
+
+	_, err := e.Search(context.Background(), Query{Text: "q", Expand: &ExpandOptions{TopK: 300}})
+	require.NoError(t, err)
+	_, err = e.Search(context.Background(), Query{Text: "q", Expand: &ExpandOptions{TopK: 501}})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
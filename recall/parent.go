@@ -0,0 +1,133 @@
+package recall
+
+import (
+	"context"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"sort"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+// defaultChildFactor widens the child search so that enough distinct
+// parents survive deduplication
+const defaultChildFactor = 4
+
+// ParentGraph resolves child chunks to their parents
+type ParentGraph interface {
+	ParentsOfChildren(ctx context.Context, chunkIDs []string) ([]graph.ChildParent, error)
+}
+
+// ChildHit is a matched child chunk kept as evidence for its parent
+type ChildHit struct {
+	Child graph.ChildNode `json:"child"`
+	Hit   Hit             `json:"hit"`
+}
+
+// ParentResult is a parent chunk scored by its best matching child
+type ParentResult struct {
+	Parent   graph.ParentNode `json:"parent"`
+	Score    float32          `json:"score"`
+	Children []ChildHit       `json:"children"`
+}
+
+type ParentOptions struct {
+	// TopK is the number of parents returned
+	TopK int
+	// ChildTopK is the number of children searched, TopK*4 when zero
+	ChildTopK int
+	// Filter is a milvus boolean expression applied to the child search
+	Filter string
+}
+
+// ParentRetriever implements small-to-big retrieval: small child chunks
+// are matched precisely in milvus and the larger parent chunks, reached via
+// HAS_CHILD in neo4j, are returned as context.
+type ParentRetriever struct {
+	vectors Searcher
+	graph   ParentGraph
+	metric  entity.MetricType
+}
+
+func NewParentRetriever(vectors Searcher, graph ParentGraph, metric entity.MetricType) *ParentRetriever {
+	return &ParentRetriever{vectors: vectors, graph: graph, metric: metric}
+}
+
+// Retrieve searches child chunks in the precise collection and groups the
+// hits by parent, best parent first
+func (r *ParentRetriever) Retrieve(ctx context.Context, vector []float32, opts ParentOptions) ([]ParentResult, error) {
+	if opts.TopK <= 0 {
+		return nil, nil
+	}
+	childTopK := opts.ChildTopK
+	if childTopK <= 0 {
+		childTopK = opts.TopK * defaultChildFactor
+	}
+	hits, err := r.vectors.Search(ctx, SearchRequest{
+		Collection:   schema.RecallPreciseCollection,
+		Vector:       vector,
+		TopK:         childTopK,
+		Filter:       opts.Filter,
+		OutputFields: ChunkFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	links, err := r.graph.ParentsOfChildren(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return groupByParent(hits, links, r.metric, opts.TopK), nil
+}
+
+// groupByParent deduplicates hits by parent. A parent scores as its best
+// child; children without a parent in the graph are dropped.
+func groupByParent(hits []Hit, links []graph.ChildParent, metric entity.MetricType, topK int) []ParentResult {
+	byChunk := make(map[string]graph.ChildParent, len(links))
+	for _, l := range links {
+		byChunk[l.Child.ChunkID] = l
+	}
+
+	index := make(map[string]int)
+	var results []ParentResult
+	for _, h := range hits {
+		link, ok := byChunk[h.ID]
+		if !ok {
+			continue
+		}
+		evidence := ChildHit{Child: link.Child, Hit: h}
+		i, seen := index[link.Parent.NodeID]
+		if !seen {
+			index[link.Parent.NodeID] = len(results)
+			results = append(results, ParentResult{Parent: link.Parent, Score: h.Score, Children: []ChildHit{evidence}})
+			continue
+		}
+		res := &results[i]
+		res.Children = append(res.Children, evidence)
+		if Better(metric, h.Score, res.Score) {
+			res.Score = h.Score
+		}
+	}
+
+	for i := range results {
+		children := results[i].Children
+		sort.SliceStable(children, func(a, b int) bool {
+			return Better(metric, children[a].Hit.Score, children[b].Hit.Score)
+		})
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return Better(metric, results[a].Score, results[b].Score)
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
@@ -0,0 +1,125 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"sea/embedding/schema/graph"
+	schema "sea/embedding/schema/vector"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSearcher 返回固定命中并记录请求，byCollection 优先于 hits
+type stubSearcher struct {
+	hits         []Hit
+	byCollection map[string][]Hit
+	err          error
+	last         SearchRequest
+	calls        []SearchRequest
+}
+
+func (s *stubSearcher) Search(ctx context.Context, req SearchRequest) ([]Hit, error) {
+	s.last = req
+	s.calls = append(s.calls, req)
+	if hits, ok := s.byCollection[req.Collection]; ok {
+		return hits, s.err
+	}
+	return s.hits, s.err
+}
+
+// stubGraph 按 chunk id 查父节点，扩展时返回固定邻居
+type stubGraph struct {
+	links     map[string]graph.ChildParent
+	asked     []string
+	neighbors []graph.Neighbor
+	expanded  []string
+	expandOpt graph.ExpandOptions
+}
+
+func (g *stubGraph) Expand(ctx context.Context, seeds []string, opts graph.ExpandOptions) ([]graph.Neighbor, error) {
+	g.expanded = seeds
+	g.expandOpt = opts
+	return g.neighbors, nil
+}
+
+func (g *stubGraph) ParentsOfChildren(ctx context.Context, chunkIDs []string) ([]graph.ChildParent, error) {
+	g.asked = chunkIDs
+	var out []graph.ChildParent
+	for _, id := range chunkIDs {
+		if l, ok := g.links[id]; ok {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func link(child, parent string) graph.ChildParent {
+	return graph.ChildParent{
+		Child:  graph.ChildNode{NodeID: child, ChunkID: child, ParentNodeID: parent},
+		Parent: graph.ParentNode{NodeID: parent, ChunkID: parent},
+	}
+}
+
+func newStubGraph(links ...graph.ChildParent) *stubGraph {
+	g := &stubGraph{links: make(map[string]graph.ChildParent)}
+	for _, l := range links {
+		g.links[l.Child.ChunkID] = l
+	}
+	return g
+}
+
+// 子块命中按父块去重，父块分数取最好的子块，子块保留为证据
+func TestParentRetrieverGroupsByParent(t *testing.T) {
+	vectors := &stubSearcher{hits: []Hit{
+		{ID: "c1", Score: 0.9},
+		{ID: "c3", Score: 0.8},
+		{ID: "c2", Score: 0.7},
+		{ID: "orphan", Score: 0.95},
+	}}
+	g := newStubGraph(link("c1", "p1"), link("c2", "p1"), link("c3", "p2"))
+	r := NewParentRetriever(vectors, g, entity.COSINE)
+
+	results, err := r.Retrieve(context.Background(), []float32{1, 0}, ParentOptions{TopK: 5, Filter: `tag == "go"`})
+	require.NoError(t, err)
+
+	assert.Equal(t, schema.RecallPreciseCollection, vectors.last.Collection)
+	assert.Equal(t, 20, vectors.last.TopK)
+	assert.Equal(t, `tag == "go"`, vectors.last.Filter)
+	assert.Equal(t, []string{"c1", "c3", "c2", "orphan"}, g.asked)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "p1", results[0].Parent.NodeID)
+	assert.InDelta(t, 0.9, results[0].Score, 1e-6)
+	require.Len(t, results[0].Children, 2)
+	assert.Equal(t, "c1", results[0].Children[0].Child.ChunkID)
+	assert.Equal(t, "c2", results[0].Children[1].Child.ChunkID)
+	assert.Equal(t, "p2", results[1].Parent.NodeID)
+}
+
+// L2 距离越小越好
+func TestGroupByParentL2(t *testing.T) {
+	hits := []Hit{{ID: "c1", Score: 0.5}, {ID: "c2", Score: 0.1}, {ID: "c3", Score: 0.3}}
+	links := []graph.ChildParent{link("c1", "p1"), link("c2", "p2"), link("c3", "p1")}
+
+	results := groupByParent(hits, links, entity.L2, 1)
+	require.Len(t, results, 1)
+	assert.Equal(t, "p2", results[0].Parent.NodeID)
+	assert.InDelta(t, 0.1, results[0].Score, 1e-6)
+}
+
+// 向量检索出错直接返回，没有命中不查图
+func TestParentRetrieverErrors(t *testing.T) {
+	g := newStubGraph()
+	r := NewParentRetriever(&stubSearcher{err: errors.New("milvus down")}, g, entity.COSINE)
+	_, err := r.Retrieve(context.Background(), []float32{1}, ParentOptions{TopK: 3})
+	assert.EqualError(t, err, "milvus down")
+
+	r = NewParentRetriever(&stubSearcher{}, g, entity.COSINE)
+	results, err := r.Retrieve(context.Background(), []float32{1}, ParentOptions{TopK: 3, ChildTopK: 7})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Nil(t, g.asked)
+}
@@ -0,0 +1,205 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	schema "sea/embedding/schema/vector"
+	"sea/embedding/vecutil"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+// ChunkFields are the dynamic fields returned with every chunk hit
+var ChunkFields = []string{
+	schema.FieldTag,
+	schema.FieldArticleID,
+	schema.FieldParentID,
+	schema.FieldNodeID,
+	schema.FieldText,
+}
+
+// Hit is one vector search match. Score is the raw milvus score, so whether
+// larger is better depends on the metric; see Better.
+type Hit struct {
+	ID     string         `json:"id"`
+	Score  float32        `json:"score"`
+	Fields map[string]any `json:"fields,omitempty"`
+	// Source and Provenance are set when graph expansion is on
+	Source     string      `json:"source,omitempty"`
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// SearchRequest is one ANN search on a single collection
+type SearchRequest struct {
+	Collection   string
+	Vector       []float32
+	TopK         int
+	Filter       string
+	OutputFields []string
+}
+
+// Searcher runs ANN searches
+type Searcher interface {
+	Search(ctx context.Context, req SearchRequest) ([]Hit, error)
+}
+
+// VectorSearch searches the recall collections through a milvus client
+type VectorSearch struct {
+	client *milvusclient.Client
+}
+
+func NewVectorSearch(client *milvusclient.Client) *VectorSearch {
+	return &VectorSearch{client: client}
+}
+
+func (s *VectorSearch) Search(ctx context.Context, req SearchRequest) ([]Hit, error) {
+	if s.client == nil {
+		return nil, errors.New("recall: milvus client not initialized")
+	}
+	if req.TopK <= 0 {
+		return nil, fmt.Errorf("recall: top_k must be positive, got %d", req.TopK)
+	}
+	opt := milvusclient.NewSearchOption(req.Collection, req.TopK, []entity.Vector{queryVector(req.Collection, req.Vector)}).
+		WithANNSField(schema.FieldVector)
+	if req.Filter != "" {
+		opt = opt.WithFilter(req.Filter)
+	}
+	if len(req.OutputFields) > 0 {
+		opt = opt.WithOutputFields(req.OutputFields...)
+	}
+	results, err := s.client.Search(ctx, opt)
+	if err != nil {
+		return nil, fmt.Errorf("search %s: %w", req.Collection, err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return hitsFromResult(results[0])
+}
+
+// queryVector encodes v in the element type of the collection's vector
+// field, which milvus requires of search vectors
+func queryVector(collection string, v []float32) entity.Vector {
+	if schema.VectorType(collection) == entity.FieldTypeFloat16Vector {
+		return entity.Float16Vector(vecutil.ToFloat16(v))
+	}
+	return entity.FloatVector(v)
+}
+
+// Fusion methods for hybrid search
+const (
+	FusionRRF      = "rrf"
+	FusionWeighted = "weighted"
+
+	defaultRRFK = 60
+)
+
+// Fusion selects how the dense and BM25 rankings are merged. RRF uses only
+// ranks and K; weighted combines the normalized scores.
+type Fusion struct {
+	Method       string  `json:"method"`
+	K            float64 `json:"k,omitempty"`
+	DenseWeight  float64 `json:"dense_weight,omitempty"`
+	SparseWeight float64 `json:"sparse_weight,omitempty"`
+}
+
+// HybridRequest searches the dense vector and BM25 over text in one call
+type HybridRequest struct {
+	Collection string
+	Vector     []float32
+	Text       string
+	// TopK is the number of fused hits, RouteTopK the width of each route
+	TopK         int
+	RouteTopK    int
+	Filter       string
+	OutputFields []string
+	Fusion       Fusion
+}
+
+// HybridSearcher is implemented by searchers that support hybrid search
+type HybridSearcher interface {
+	HybridSearch(ctx context.Context, req HybridRequest) ([]Hit, error)
+}
+
+// HybridSearch fuses a dense ANN route and a BM25 route. Fused scores are
+// larger-is-better whatever the dense metric is.
+func (s *VectorSearch) HybridSearch(ctx context.Context, req HybridRequest) ([]Hit, error) {
+	if s.client == nil {
+		return nil, errors.New("recall: milvus client not initialized")
+	}
+	if req.TopK <= 0 || req.RouteTopK <= 0 {
+		return nil, fmt.Errorf("recall: top_k must be positive, got %d/%d", req.TopK, req.RouteTopK)
+	}
+	dense := milvusclient.NewAnnRequest(schema.FieldVector, req.RouteTopK, queryVector(req.Collection, req.Vector))
+	sparse := milvusclient.NewAnnRequest(schema.FieldSparse, req.RouteTopK, entity.Text(req.Text))
+	if req.Filter != "" {
+		dense = dense.WithFilter(req.Filter)
+		sparse = sparse.WithFilter(req.Filter)
+	}
+	opt := milvusclient.NewHybridSearchOption(req.Collection, req.TopK, dense, sparse).
+		WithReranker(reranker(req.Fusion))
+	if len(req.OutputFields) > 0 {
+		opt = opt.WithOutputFields(req.OutputFields...)
+	}
+	results, err := s.client.HybridSearch(ctx, opt)
+	if err != nil {
+		return nil, fmt.Errorf("hybrid search %s: %w", req.Collection, err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return hitsFromResult(results[0])
+}
+
+// reranker maps a validated Fusion onto a milvus reranker; the weights are
+// in the order of the ann requests, dense first
+func reranker(f Fusion) milvusclient.Reranker {
+	if f.Method == FusionWeighted {
+		return milvusclient.NewWeightedReranker([]float64{f.DenseWeight, f.SparseWeight})
+	}
+	k := f.K
+	if k <= 0 {
+		k = defaultRRFK
+	}
+	return milvusclient.NewRRFReranker().WithK(k)
+}
+
+func hitsFromResult(rs milvusclient.ResultSet) ([]Hit, error) {
+	if rs.Err != nil {
+		return nil, rs.Err
+	}
+	hits := make([]Hit, 0, rs.ResultCount)
+	for i := 0; i < rs.ResultCount; i++ {
+		id, err := rs.IDs.GetAsString(i)
+		if err != nil {
+			return nil, fmt.Errorf("read id: %w", err)
+		}
+		hit := Hit{ID: id, Score: rs.Scores[i]}
+		if len(rs.Fields) > 0 {
+			hit.Fields = make(map[string]any, len(rs.Fields))
+			for _, col := range rs.Fields {
+				if null, _ := col.IsNull(i); null {
+					continue
+				}
+				v, err := col.Get(i)
+				if err != nil {
+					continue
+				}
+				hit.Fields[col.Name()] = v
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// Better reports whether score a ranks before b under metric. Distances
+// (L2) rank ascending, similarities (COSINE, IP) descending.
+func Better(metric entity.MetricType, a, b float32) bool {
+	if metric == entity.L2 {
+		return a < b
+	}
+	return a > b
+}